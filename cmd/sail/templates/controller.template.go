@@ -18,6 +18,6 @@ func (c *{{.Name}}Controller) RegisterRoutes(router *sail.Router) {
 }
 
 func (c *{{.Name}}Controller) handle(w http.ResponseWriter, r *http.Request) {
-	c.WriteJSON(w, map[string]string{"message": "Welcome to your Sail application from {{.Name}}!"})
+	c.WriteJSON(w, r, map[string]string{"message": "Welcome to your Sail application from {{.Name}}!"})
 }
 `
@@ -0,0 +1,49 @@
+package cache
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Entry is a cached response.
+type Entry struct {
+	Status int
+	Header http.Header
+	Body   []byte
+	Tags   []string      // Tags used for invalidation, e.g. "products".
+	Stored time.Time     // When the response was generated.
+	TTL    time.Duration // How long the entry is fresh.
+	Stale  time.Duration // How long after TTL the entry may be served while it is revalidated.
+	// Vary marks an entry that holds no response: responses for its key
+	// vary on these request headers and are stored under variant keys.
+	Vary []string
+}
+
+// Fresh reports whether the entry can be served without revalidation.
+func (e *Entry) Fresh(now time.Time) bool {
+	return now.Before(e.Stored.Add(e.TTL))
+}
+
+// Expires returns the time after which the entry must not be served at all.
+func (e *Entry) Expires() time.Time {
+	return e.Stored.Add(e.TTL + e.Stale)
+}
+
+// Store holds cached responses. Implementations backed by a shared service
+// (e.g. Redis) let several instances share one cache.
+type Store interface {
+	// Get returns the entry for key, if present and not expired.
+	Get(ctx context.Context, key string) (*Entry, bool, error)
+	// Set stores an entry, replacing any entry with the same key.
+	Set(ctx context.Context, key string, e *Entry) error
+	// Delete removes the entry for key.
+	Delete(ctx context.Context, key string) error
+	// InvalidateTags removes every entry carrying one of tags.
+	InvalidateTags(ctx context.Context, tags ...string) error
+	// TagGeneration returns a counter that grows whenever one of tags is
+	// invalidated. Callers read it before generating a response and skip
+	// storing the response if it has changed, so that an invalidation that
+	// happens meanwhile is not undone.
+	TagGeneration(ctx context.Context, tags ...string) (uint64, error)
+}
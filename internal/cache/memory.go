@@ -0,0 +1,134 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// memoryItem is an LRU list element.
+type memoryItem struct {
+	key   string
+	entry *Entry
+}
+
+// MemoryStore is an in-process Store that evicts the least recently used
+// entry once it holds Capacity entries.
+type MemoryStore struct {
+	mu       sync.Mutex
+	capacity int
+	lru      *list.List // Front is most recently used.
+	items    map[string]*list.Element
+	tags     map[string]map[string]struct{} // Tag -> keys.
+	gens     map[string]uint64              // Tag -> invalidation count.
+}
+
+// NewMemoryStore creates a store holding at most capacity entries.
+// A capacity of zero or less defaults to 1000.
+func NewMemoryStore(capacity int) *MemoryStore {
+	if capacity <= 0 {
+		capacity = 1000
+	}
+	return &MemoryStore{
+		capacity: capacity,
+		lru:      list.New(),
+		items:    make(map[string]*list.Element),
+		tags:     make(map[string]map[string]struct{}),
+		gens:     make(map[string]uint64),
+	}
+}
+
+// Get implements Store.
+func (s *MemoryStore) Get(_ context.Context, key string) (*Entry, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	el, ok := s.items[key]
+	if !ok {
+		return nil, false, nil
+	}
+	item := el.Value.(*memoryItem)
+	if time.Now().After(item.entry.Expires()) {
+		s.remove(el)
+		return nil, false, nil
+	}
+	s.lru.MoveToFront(el)
+	return item.entry, true, nil
+}
+
+// Set implements Store.
+func (s *MemoryStore) Set(_ context.Context, key string, e *Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if el, ok := s.items[key]; ok {
+		s.remove(el)
+	}
+	s.items[key] = s.lru.PushFront(&memoryItem{key: key, entry: e})
+	for _, tag := range e.Tags {
+		if s.tags[tag] == nil {
+			s.tags[tag] = make(map[string]struct{})
+		}
+		s.tags[tag][key] = struct{}{}
+	}
+	for s.lru.Len() > s.capacity {
+		s.remove(s.lru.Back())
+	}
+	return nil
+}
+
+// Delete implements Store.
+func (s *MemoryStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if el, ok := s.items[key]; ok {
+		s.remove(el)
+	}
+	return nil
+}
+
+// InvalidateTags implements Store.
+func (s *MemoryStore) InvalidateTags(_ context.Context, tags ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, tag := range tags {
+		s.gens[tag]++
+		for key := range s.tags[tag] {
+			if el, ok := s.items[key]; ok {
+				s.remove(el)
+			}
+		}
+	}
+	return nil
+}
+
+// TagGeneration implements Store.
+func (s *MemoryStore) TagGeneration(_ context.Context, tags ...string) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var gen uint64
+	for _, tag := range tags {
+		gen += s.gens[tag]
+	}
+	return gen, nil
+}
+
+// Len returns the number of stored entries.
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lru.Len()
+}
+
+// remove deletes an element and its tag references.
+func (s *MemoryStore) remove(el *list.Element) {
+	item := s.lru.Remove(el).(*memoryItem)
+	delete(s.items, item.key)
+	for _, tag := range item.entry.Tags {
+		if keys := s.tags[tag]; keys != nil {
+			delete(keys, item.key)
+			if len(keys) == 0 {
+				delete(s.tags, tag)
+			}
+		}
+	}
+}
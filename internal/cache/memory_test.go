@@ -0,0 +1,69 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	fresh := func(tags ...string) *Entry {
+		return &Entry{Status: 200, Tags: tags, Stored: time.Now(), TTL: time.Minute}
+	}
+	tests := []struct {
+		name string
+		run  func(s *MemoryStore)
+		want []string // Keys still present.
+	}{
+		{"evicts least recently used", func(s *MemoryStore) {
+			s.Set(ctx, "a", fresh())
+			s.Set(ctx, "b", fresh())
+			s.Get(ctx, "a")
+			s.Set(ctx, "c", fresh())
+		}, []string{"a", "c"}},
+		{"invalidates tags", func(s *MemoryStore) {
+			s.Set(ctx, "a", fresh("x"))
+			s.Set(ctx, "b", fresh("y"))
+			s.InvalidateTags(ctx, "x")
+		}, []string{"b"}},
+		{"drops expired entries", func(s *MemoryStore) {
+			s.Set(ctx, "a", &Entry{Stored: time.Now().Add(-time.Hour), TTL: time.Minute})
+		}, nil},
+		{"replacing an entry drops its old tags", func(s *MemoryStore) {
+			s.Set(ctx, "a", fresh("x"))
+			s.Set(ctx, "a", fresh("y"))
+			s.InvalidateTags(ctx, "x")
+		}, []string{"a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewMemoryStore(2)
+			tt.run(s)
+			for _, key := range []string{"a", "b", "c"} {
+				_, ok, _ := s.Get(ctx, key)
+				want := false
+				for _, k := range tt.want {
+					want = want || k == key
+				}
+				if ok != want {
+					t.Fatalf("key %s present = %v, want %v", key, ok, want)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryStoreTagGeneration(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore(10)
+	before, _ := s.TagGeneration(ctx, "x", "y")
+	s.InvalidateTags(ctx, "z")
+	if gen, _ := s.TagGeneration(ctx, "x", "y"); gen != before {
+		t.Fatalf("unrelated invalidation changed the generation")
+	}
+	s.InvalidateTags(ctx, "y")
+	if gen, _ := s.TagGeneration(ctx, "x", "y"); gen == before {
+		t.Fatalf("invalidation did not change the generation")
+	}
+}
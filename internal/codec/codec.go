@@ -0,0 +1,237 @@
+package codec
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Codec encodes response bodies and decodes request bodies for one media type.
+// Additional formats such as MessagePack, CBOR or protobuf are added by
+// registering an implementation of this interface.
+type Codec interface {
+	// ContentType returns the media type handled by the codec, e.g. "application/json".
+	ContentType() string
+	Encode(w io.Writer, v interface{}) error
+	Decode(r io.Reader, v interface{}) error
+}
+
+// jsonCodec implements Codec using encoding/json.
+type jsonCodec struct{}
+
+// JSON returns the built-in JSON codec.
+func JSON() Codec { return jsonCodec{} }
+
+func (jsonCodec) ContentType() string { return "application/json" }
+
+func (jsonCodec) Encode(w io.Writer, v interface{}) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+func (jsonCodec) Decode(r io.Reader, v interface{}) error {
+	return json.NewDecoder(r).Decode(v)
+}
+
+// xmlCodec implements Codec using encoding/xml.
+type xmlCodec struct{}
+
+// XML returns the built-in XML codec.
+func XML() Codec { return xmlCodec{} }
+
+func (xmlCodec) ContentType() string { return "application/xml" }
+
+func (xmlCodec) Encode(w io.Writer, v interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	return xml.NewEncoder(w).Encode(v)
+}
+
+func (xmlCodec) Decode(r io.Reader, v interface{}) error {
+	return xml.NewDecoder(r).Decode(v)
+}
+
+// aliases maps alternative media types to the type of a registered codec.
+// It is used both for Content-Type lookups and Accept negotiation.
+var aliases = map[string]string{
+	"text/json": "application/json",
+	"text/xml":  "application/xml",
+}
+
+// Registry holds the codecs available for content negotiation.
+// The first registered codec is the default used when the client accepts anything.
+type Registry struct {
+	mu     sync.RWMutex
+	codecs []Codec
+}
+
+// NewRegistry creates a registry containing the given codecs.
+func NewRegistry(codecs ...Codec) *Registry {
+	r := &Registry{}
+	for _, c := range codecs {
+		r.Register(c)
+	}
+	return r
+}
+
+// Register adds a codec, replacing any codec registered for the same media type.
+func (r *Registry) Register(c Codec) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, existing := range r.codecs {
+		if existing.ContentType() == c.ContentType() {
+			r.codecs[i] = c
+			return
+		}
+	}
+	r.codecs = append(r.codecs, c)
+}
+
+// lookup returns the codec registered for an exact media type.
+func (r *Registry) lookup(mediaType string) (Codec, bool) {
+	for _, c := range r.codecs {
+		if c.ContentType() == mediaType {
+			return c, true
+		}
+	}
+	return nil, false
+}
+
+// ForContentType returns the codec for a Content-Type header value.
+// Structured syntax suffixes such as "application/problem+json" fall back
+// to the codec of the base format.
+func (r *Registry) ForContentType(contentType string) (Codec, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if c, ok := r.lookup(mediaType); ok {
+		return c, true
+	}
+	if alias, ok := aliases[mediaType]; ok {
+		return r.lookup(alias)
+	}
+	if i := strings.LastIndexByte(mediaType, '+'); i >= 0 {
+		return r.lookup("application/" + mediaType[i+1:])
+	}
+	return nil, false
+}
+
+// mediaRange is one entry of an Accept header.
+type mediaRange struct {
+	typ, subtype string
+	q            float64
+	order        int
+}
+
+// specificity ranks exact types above type/* and */*.
+func (m mediaRange) specificity() int {
+	switch {
+	case m.typ == "*":
+		return 0
+	case m.subtype == "*":
+		return 1
+	}
+	return 2
+}
+
+// parseAccept parses an Accept header, ordered by preference.
+func parseAccept(accept string) []mediaRange {
+	var ranges []mediaRange
+	for i, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		typ, subtype, _ := strings.Cut(mediaType, "/")
+		q := 1.0
+		if qs, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(qs, 64); err == nil {
+				q = parsed
+			}
+		}
+		ranges = append(ranges, mediaRange{typ: typ, subtype: subtype, q: q, order: i})
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		if ranges[i].q != ranges[j].q {
+			return ranges[i].q > ranges[j].q
+		}
+		return ranges[i].specificity() > ranges[j].specificity()
+	})
+	return ranges
+}
+
+// Negotiate selects the codec that best matches an Accept header.
+// An empty header selects the default codec. It returns false when
+// no registered codec is acceptable.
+func (r *Registry) Negotiate(accept string) (Codec, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.codecs) == 0 {
+		return nil, false
+	}
+	if strings.TrimSpace(accept) == "" {
+		return r.codecs[0], true
+	}
+	ranges := parseAccept(accept)
+	for _, m := range ranges {
+		if m.q <= 0 {
+			continue
+		}
+		for _, c := range r.codecs {
+			if matches(m, mediaTypes(c.ContentType()), ranges) {
+				return c, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// mediaTypes returns a codec's media type followed by its aliases.
+func mediaTypes(contentType string) []string {
+	names := []string{contentType}
+	for alias, target := range aliases {
+		if target == contentType {
+			names = append(names, alias)
+		}
+	}
+	return names
+}
+
+// matches reports whether a media range accepts one of a codec's media
+// types and none of them was explicitly refused with q=0 elsewhere in the header.
+func matches(m mediaRange, names []string, ranges []mediaRange) bool {
+	accepted := false
+	for _, name := range names {
+		typ, subtype, _ := strings.Cut(name, "/")
+		if covers(m, typ, subtype) {
+			accepted = true
+		}
+		for _, other := range ranges {
+			if other.q <= 0 && other.specificity() >= m.specificity() && covers(other, typ, subtype) {
+				return false
+			}
+		}
+	}
+	return accepted
+}
+
+// covers reports whether a media range includes typ/subtype.
+func covers(m mediaRange, typ, subtype string) bool {
+	return m.typ == "*" || (m.typ == typ && (m.subtype == "*" || m.subtype == subtype))
+}
+
+// defaultRegistry holds the built-in JSON and XML codecs.
+var defaultRegistry = NewRegistry(JSON(), XML())
+
+// Default returns the package-level registry.
+func Default() *Registry {
+	return defaultRegistry
+}
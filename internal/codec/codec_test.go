@@ -0,0 +1,60 @@
+package codec
+
+import "testing"
+
+func TestNegotiate(t *testing.T) {
+	r := NewRegistry(JSON(), XML())
+	tests := []struct {
+		accept string
+		want   string
+		ok     bool
+	}{
+		{"", "application/json", true},
+		{"*/*", "application/json", true},
+		{"application/xml", "application/xml", true},
+		{"text/xml", "application/xml", true},
+		{"text/json", "application/json", true},
+		{"text/*", "application/json", true},
+		{"application/json;q=0.5, application/xml", "application/xml", true},
+		{"*/*, application/json;q=0", "application/xml", true},
+		{"text/html", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			c, ok := r.Negotiate(tt.accept)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if ok && c.ContentType() != tt.want {
+				t.Fatalf("got %s, want %s", c.ContentType(), tt.want)
+			}
+		})
+	}
+}
+
+func TestForContentType(t *testing.T) {
+	r := NewRegistry(JSON(), XML())
+	tests := []struct {
+		contentType string
+		want        string
+		ok          bool
+	}{
+		{"application/json; charset=utf-8", "application/json", true},
+		{"text/json", "application/json", true},
+		{"text/xml", "application/xml", true},
+		{"application/problem+json", "application/json", true},
+		{"application/x-www-form-urlencoded", "", false},
+		{"not a type;;", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			c, ok := r.ForContentType(tt.contentType)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if ok && c.ContentType() != tt.want {
+				t.Fatalf("got %s, want %s", c.ContentType(), tt.want)
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import "sync"
 // Container provides a simple, thread-safe dependency injection container.
 type Container struct {
 	providers map[string]interface{}
+	parent    *Container
 	mu        sync.RWMutex
 }
 
@@ -22,11 +23,24 @@ func (c *Container) Register(name string, provider interface{}) {
 	c.providers[name] = provider
 }
 
-// Resolve retrieves a provider by name.
+// CreateScope returns a child container, e.g. for a single request.
+// Providers registered on the scope shadow those of its parent and are
+// discarded with the scope; unresolved names fall back to the parent.
+func (c *Container) CreateScope() *Container {
+	scope := NewContainer()
+	scope.parent = c
+	return scope
+}
+
+// Resolve retrieves a provider by name, consulting parent containers
+// when the name is not registered locally.
 func (c *Container) Resolve(name string) (interface{}, bool) {
 	c.mu.RLock()
-	defer c.mu.RUnlock()
 	p, ok := c.providers[name]
+	c.mu.RUnlock()
+	if !ok && c.parent != nil {
+		return c.parent.Resolve(name)
+	}
 	return p, ok
 }
 
@@ -34,10 +34,52 @@ type Logger interface {
 	Debug(msg string)
 }
 
+// FieldLogger is implemented by loggers that support structured fields.
+type FieldLogger interface {
+	// WithField returns a new logger instance that appends key=value to every message.
+	WithField(key, value string) Logger
+}
+
+// WithField returns a logger that appends key=value to every message.
+// Loggers implementing FieldLogger add the field themselves; others are
+// wrapped so that the field is appended to the message text.
+func WithField(l Logger, key, value string) Logger {
+	if fl, ok := l.(FieldLogger); ok {
+		return fl.WithField(key, value)
+	}
+	return &fieldWrapper{Logger: l, suffix: " " + key + "=" + value}
+}
+
+// fieldWrapper adds fields to loggers that do not implement FieldLogger.
+type fieldWrapper struct {
+	Logger
+	suffix string
+}
+
+func (w *fieldWrapper) WithContext(ctx string) Logger {
+	return &fieldWrapper{Logger: w.Logger.WithContext(ctx), suffix: w.suffix}
+}
+
+func (w *fieldWrapper) WithField(key, value string) Logger {
+	return &fieldWrapper{Logger: w.Logger, suffix: w.suffix + " " + key + "=" + value}
+}
+
+func (w *fieldWrapper) Info(msg string)  { w.Logger.Info(msg + w.suffix) }
+func (w *fieldWrapper) Warn(msg string)  { w.Logger.Warn(msg + w.suffix) }
+func (w *fieldWrapper) Error(msg string) { w.Logger.Error(msg + w.suffix) }
+func (w *fieldWrapper) Debug(msg string) { w.Logger.Debug(msg + w.suffix) }
+
+// field is a key/value pair appended to log messages.
+type field struct {
+	key   string
+	value string
+}
+
 // DefaultLogger is a basic logger using the standard log package.
 type DefaultLogger struct {
 	level   Level
 	context string
+	fields  []field
 }
 
 // New returns a new instance of the default logger.
@@ -67,6 +109,22 @@ func (l *DefaultLogger) WithContext(ctx string) Logger {
 	return &DefaultLogger{
 		level:   l.level,
 		context: ctx,
+		fields:  l.fields,
+	}
+}
+
+// WithField returns a new logger instance that appends key=value to every message.
+func (l *DefaultLogger) WithField(key, value string) Logger {
+	fields := make([]field, 0, len(l.fields)+1)
+	for _, f := range l.fields {
+		if f.key != key {
+			fields = append(fields, f)
+		}
+	}
+	return &DefaultLogger{
+		level:   l.level,
+		context: l.context,
+		fields:  append(fields, field{key: key, value: value}),
 	}
 }
 
@@ -86,6 +144,9 @@ func (l *DefaultLogger) formatMsg(prefix string, msg string) string {
 		color = ""
 	}
 
+	for _, f := range l.fields {
+		msg += " " + f.key + "=" + f.value
+	}
 	if l.context != "" {
 		return color + prefix + " [" + l.context + "]: " + msg + ColorReset
 	}
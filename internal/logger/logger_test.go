@@ -0,0 +1,36 @@
+package logger
+
+import (
+	"strings"
+	"testing"
+)
+
+// recordLogger implements only Logger, without FieldLogger.
+type recordLogger struct {
+	lines *[]string
+}
+
+func (l recordLogger) WithContext(string) Logger { return l }
+func (l recordLogger) Info(msg string)           { *l.lines = append(*l.lines, msg) }
+func (l recordLogger) Warn(msg string)           { *l.lines = append(*l.lines, msg) }
+func (l recordLogger) Error(msg string)          { *l.lines = append(*l.lines, msg) }
+func (l recordLogger) Debug(msg string)          { *l.lines = append(*l.lines, msg) }
+
+func TestWithField(t *testing.T) {
+	var lines []string
+	var l Logger = recordLogger{lines: &lines}
+	l = WithField(l, "request_id", "abc")
+	l = WithField(l.WithContext("HTTP"), "user", "bob")
+	l.Info("hello")
+	if len(lines) != 1 || lines[0] != "hello request_id=abc user=bob" {
+		t.Fatalf("lines = %q", lines)
+	}
+}
+
+func TestDefaultLoggerWithField(t *testing.T) {
+	l := WithField(WithField(New(), "a", "1"), "a", "2").(*DefaultLogger)
+	msg := l.formatMsg("INFO", "hi")
+	if !strings.Contains(msg, "hi a=2") || strings.Contains(msg, "a=1") {
+		t.Fatalf("formatMsg = %q", msg)
+	}
+}
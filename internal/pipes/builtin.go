@@ -0,0 +1,102 @@
+package pipes
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/SailfinIO/sail/internal/validation"
+)
+
+// ParseInt converts a string to an int.
+func ParseInt() Pipe {
+	return PipeFunc(func(value interface{}, meta Metadata) (interface{}, error) {
+		switch v := value.(type) {
+		case int:
+			return v, nil
+		case string:
+			if i, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+				return i, nil
+			}
+		}
+		return nil, NewError(meta, "int", "numeric string is expected")
+	})
+}
+
+// ParseFloat converts a string to a float64.
+func ParseFloat() Pipe {
+	return PipeFunc(func(value interface{}, meta Metadata) (interface{}, error) {
+		switch v := value.(type) {
+		case float64:
+			return v, nil
+		case string:
+			if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				return f, nil
+			}
+		}
+		return nil, NewError(meta, "float", "numeric string is expected")
+	})
+}
+
+// ParseBool converts a string such as "true" or "0" to a bool.
+func ParseBool() Pipe {
+	return PipeFunc(func(value interface{}, meta Metadata) (interface{}, error) {
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			if b, err := strconv.ParseBool(strings.TrimSpace(v)); err == nil {
+				return b, nil
+			}
+		}
+		return nil, NewError(meta, "bool", "boolean string is expected")
+	})
+}
+
+// ParseUUID checks that a string is a UUID and returns it in lower case.
+func ParseUUID() Pipe {
+	return PipeFunc(func(value interface{}, meta Metadata) (interface{}, error) {
+		if s, ok := value.(string); ok && validation.IsUUID(s) {
+			return strings.ToLower(s), nil
+		}
+		return nil, NewError(meta, "uuid", "uuid is expected")
+	})
+}
+
+// ParseEnum checks that a string is one of the allowed values.
+func ParseEnum(allowed ...string) Pipe {
+	return PipeFunc(func(value interface{}, meta Metadata) (interface{}, error) {
+		if s, ok := value.(string); ok {
+			for _, a := range allowed {
+				if s == a {
+					return s, nil
+				}
+			}
+		}
+		return nil, NewError(meta, "enum", fmt.Sprintf("must be one of [%s]", strings.Join(allowed, " ")))
+	})
+}
+
+// DefaultValue substitutes def when the parameter is missing or empty.
+func DefaultValue(def interface{}) Pipe {
+	return PipeFunc(func(value interface{}, meta Metadata) (interface{}, error) {
+		if value == nil {
+			return def, nil
+		}
+		if s, ok := value.(string); ok && s == "" {
+			return def, nil
+		}
+		return value, nil
+	})
+}
+
+// Trim removes leading and trailing white space from strings.
+// Other values are passed through unchanged.
+func Trim() Pipe {
+	return PipeFunc(func(value interface{}, meta Metadata) (interface{}, error) {
+		if s, ok := value.(string); ok {
+			return strings.TrimSpace(s), nil
+		}
+		return value, nil
+	})
+}
@@ -0,0 +1,105 @@
+package pipes
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/SailfinIO/sail/internal/server"
+	"github.com/SailfinIO/sail/internal/validation"
+)
+
+// Source identifies where a parameter is read from.
+type Source string
+
+const (
+	SourcePath   Source = "param"
+	SourceQuery  Source = "query"
+	SourceHeader Source = "header"
+)
+
+// Metadata describes the parameter a pipe is applied to.
+type Metadata struct {
+	Source Source
+	Name   string
+}
+
+// Pipe transforms or validates a single input value, similar to NestJS pipes.
+// A missing parameter is passed as nil.
+type Pipe interface {
+	Transform(value interface{}, meta Metadata) (interface{}, error)
+}
+
+// PipeFunc adapts an ordinary function to the Pipe interface.
+type PipeFunc func(value interface{}, meta Metadata) (interface{}, error)
+
+// Transform calls f(value, meta).
+func (f PipeFunc) Transform(value interface{}, meta Metadata) (interface{}, error) {
+	return f(value, meta)
+}
+
+// Apply runs value through pipes in order and returns the final result.
+func Apply(value interface{}, meta Metadata, pipes ...Pipe) (interface{}, error) {
+	var err error
+	for _, p := range pipes {
+		if value, err = p.Transform(value, meta); err != nil {
+			return nil, err
+		}
+	}
+	return value, nil
+}
+
+// NewError builds the 400 error returned when a pipe rejects a value.
+// It uses the same shape as validation failures.
+func NewError(meta Metadata, code, message string) *server.HTTPError {
+	return &server.HTTPError{
+		Status:  http.StatusBadRequest,
+		Code:    "validation_failed",
+		Message: "Validation failed",
+		Details: validation.Errors{{
+			Field:   string(meta.Source) + "." + meta.Name,
+			Code:    code,
+			Message: message,
+		}},
+	}
+}
+
+// Raw reads the unprocessed value of a parameter from the request.
+// It returns nil when the parameter is absent.
+func Raw(r *http.Request, meta Metadata) interface{} {
+	switch meta.Source {
+	case SourcePath:
+		if v := r.PathValue(meta.Name); v != "" {
+			return v
+		}
+	case SourceQuery:
+		if values, ok := r.URL.Query()[meta.Name]; ok && len(values) > 0 {
+			return values[0]
+		}
+	case SourceHeader:
+		if values := r.Header.Values(meta.Name); len(values) > 0 {
+			return values[0]
+		}
+	}
+	return nil
+}
+
+// valuesKey is the context key for parameters already transformed by route pipes.
+type valuesKey struct{}
+
+// WithValue returns a copy of ctx recording the transformed value of a parameter.
+func WithValue(ctx context.Context, meta Metadata, value interface{}) context.Context {
+	prev, _ := ctx.Value(valuesKey{}).(map[Metadata]interface{})
+	values := make(map[Metadata]interface{}, len(prev)+1)
+	for k, v := range prev {
+		values[k] = v
+	}
+	values[meta] = value
+	return context.WithValue(ctx, valuesKey{}, values)
+}
+
+// Value returns the transformed value of a parameter recorded by WithValue.
+func Value(ctx context.Context, meta Metadata) (interface{}, bool) {
+	values, _ := ctx.Value(valuesKey{}).(map[Metadata]interface{})
+	v, ok := values[meta]
+	return v, ok
+}
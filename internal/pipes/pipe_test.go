@@ -0,0 +1,87 @@
+package pipes
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/SailfinIO/sail/internal/server"
+	"github.com/SailfinIO/sail/internal/validation"
+)
+
+func TestBuiltinPipes(t *testing.T) {
+	meta := Metadata{Source: SourceQuery, Name: "page"}
+	tests := []struct {
+		name  string
+		pipes []Pipe
+		value interface{}
+		want  interface{}
+		code  string // Code of the expected field error, empty on success.
+	}{
+		{"int", []Pipe{ParseInt()}, " 42 ", 42, ""},
+		{"int rejects text", []Pipe{ParseInt()}, "abc", nil, "int"},
+		{"int rejects missing", []Pipe{ParseInt()}, nil, nil, "int"},
+		{"float", []Pipe{ParseFloat()}, "1.5", 1.5, ""},
+		{"float rejects text", []Pipe{ParseFloat()}, "x", nil, "float"},
+		{"bool", []Pipe{ParseBool()}, "0", false, ""},
+		{"bool rejects text", []Pipe{ParseBool()}, "maybe", nil, "bool"},
+		{"uuid lower-cased", []Pipe{ParseUUID()}, "6BA7B810-9DAD-11D1-80B4-00C04FD430C8", "6ba7b810-9dad-11d1-80b4-00c04fd430c8", ""},
+		{"uuid rejects text", []Pipe{ParseUUID()}, "not-a-uuid", nil, "uuid"},
+		{"enum", []Pipe{ParseEnum("asc", "desc")}, "desc", "desc", ""},
+		{"enum rejects other", []Pipe{ParseEnum("asc", "desc")}, "up", nil, "enum"},
+		{"trim", []Pipe{Trim()}, "  a ", "a", ""},
+		{"default for missing", []Pipe{DefaultValue("1")}, nil, "1", ""},
+		{"default for empty", []Pipe{DefaultValue("1")}, "", "1", ""},
+		{"default keeps value", []Pipe{DefaultValue("1")}, "7", "7", ""},
+		{"chain default then int", []Pipe{DefaultValue("1"), ParseInt()}, nil, 1, ""},
+		{"chain trim then enum", []Pipe{Trim(), ParseEnum("asc")}, " asc ", "asc", ""},
+		{"chain stops at first error", []Pipe{ParseInt(), PipeFunc(func(interface{}, Metadata) (interface{}, error) {
+			panic("later pipe ran")
+		})}, "x", nil, "int"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Apply(tt.value, meta, tt.pipes...)
+			if tt.code == "" {
+				if err != nil || got != tt.want {
+					t.Fatalf("Apply = %v, %v, want %v", got, err, tt.want)
+				}
+				return
+			}
+			var httpErr *server.HTTPError
+			if !errors.As(err, &httpErr) || httpErr.Status != http.StatusBadRequest {
+				t.Fatalf("Apply = %v, want a 400", err)
+			}
+			fields, _ := httpErr.Details.(validation.Errors)
+			if len(fields) != 1 || fields[0].Code != tt.code || fields[0].Field != "query.page" {
+				t.Fatalf("details = %+v, want code %q on query.page", httpErr.Details, tt.code)
+			}
+		})
+	}
+}
+
+func TestRaw(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/?page=2&page=3&empty=", nil)
+	r.Header.Set("X-Tenant", "acme")
+	r.SetPathValue("id", "7")
+	tests := []struct {
+		meta Metadata
+		want interface{}
+	}{
+		{Metadata{SourcePath, "id"}, "7"},
+		{Metadata{SourcePath, "missing"}, nil},
+		{Metadata{SourceQuery, "page"}, "2"},
+		{Metadata{SourceQuery, "empty"}, ""},
+		{Metadata{SourceQuery, "missing"}, nil},
+		{Metadata{SourceHeader, "X-Tenant"}, "acme"},
+		{Metadata{SourceHeader, "X-Missing"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.meta.Source)+"."+tt.meta.Name, func(t *testing.T) {
+			if got := Raw(r, tt.meta); got != tt.want {
+				t.Fatalf("Raw = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
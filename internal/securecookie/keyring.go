@@ -0,0 +1,54 @@
+package securecookie
+
+import "time"
+
+// Keyring holds the secrets used to sign and encrypt cookies. The first
+// secret protects new cookies; the others are only used to read existing
+// ones, so a secret can be rotated without invalidating issued cookies.
+type Keyring struct {
+	signed    *Codec
+	encrypted *Codec
+}
+
+// NewKeyring creates a keyring from one or more secrets, newest first.
+func NewKeyring(secrets ...[]byte) (*Keyring, error) {
+	signed, err := New(false, secrets...)
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := New(true, secrets...)
+	if err != nil {
+		return nil, err
+	}
+	return &Keyring{signed: signed, encrypted: encrypted}, nil
+}
+
+// Codec returns the keyring's encrypting or signing codec.
+func (k *Keyring) Codec(encrypt bool) *Codec {
+	if encrypt {
+		return k.encrypted
+	}
+	return k.signed
+}
+
+// Sign returns value with an HMAC signature bound to the cookie name.
+func (k *Keyring) Sign(name, value string) (string, error) {
+	return k.signed.Encode(name, value)
+}
+
+// Verify checks a signed value and returns the original. If maxAge is
+// positive, older values are rejected.
+func (k *Keyring) Verify(name, signed string, maxAge time.Duration) (string, error) {
+	return k.signed.Decode(name, signed, maxAge)
+}
+
+// Encrypt returns value encrypted with AES-GCM and bound to the cookie name.
+func (k *Keyring) Encrypt(name, value string) (string, error) {
+	return k.encrypted.Encode(name, value)
+}
+
+// Decrypt decrypts an encrypted value. If maxAge is positive, older
+// values are rejected.
+func (k *Keyring) Decrypt(name, encrypted string, maxAge time.Duration) (string, error) {
+	return k.encrypted.Decode(name, encrypted, maxAge)
+}
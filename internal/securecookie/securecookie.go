@@ -0,0 +1,140 @@
+package securecookie
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+// Errors returned by Decode.
+var (
+	ErrInvalid = errors.New("securecookie: invalid or tampered value")
+	ErrExpired = errors.New("securecookie: value has expired")
+)
+
+// key holds the keys derived from one secret.
+type key struct {
+	sign    []byte
+	encrypt cipher.AEAD
+}
+
+// Codec signs (HMAC-SHA256) or encrypts (AES-256-GCM) cookie values.
+// Values are encoded with the first key and decoded with any key, so
+// secrets can be rotated by prepending a new one.
+type Codec struct {
+	keys    []key
+	encrypt bool
+}
+
+// New creates a Codec from one or more secrets. With encrypt the values
+// are also hidden from the client; otherwise they are only signed. It
+// returns an error if no secret is given.
+func New(encrypt bool, secrets ...[]byte) (*Codec, error) {
+	if len(secrets) == 0 {
+		return nil, errors.New("securecookie: at least one secret is required")
+	}
+	c := &Codec{encrypt: encrypt}
+	for _, secret := range secrets {
+		if len(secret) == 0 {
+			return nil, errors.New("securecookie: empty secret")
+		}
+		block, err := aes.NewCipher(derive(secret, "sail-cookie-encrypt"))
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		c.keys = append(c.keys, key{sign: derive(secret, "sail-cookie-sign"), encrypt: aead})
+	}
+	return c, nil
+}
+
+// derive returns a 32-byte key for one purpose.
+func derive(secret []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
+
+// Encode protects value for a cookie called name. The name is bound into
+// the result, so a value cannot be moved to another cookie.
+func (c *Codec) Encode(name, value string) (string, error) {
+	payload := make([]byte, 8, 8+len(value))
+	binary.BigEndian.PutUint64(payload, uint64(time.Now().Unix()))
+	payload = append(payload, value...)
+	k := c.keys[0]
+	var out []byte
+	if c.encrypt {
+		nonce := make([]byte, k.encrypt.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return "", err
+		}
+		out = k.encrypt.Seal(nonce, nonce, payload, []byte(name))
+	} else {
+		out = append(payload, sign(k.sign, name, payload)...)
+	}
+	return base64.RawURLEncoding.EncodeToString(out), nil
+}
+
+// Decode verifies an encoded value and returns the original. If maxAge
+// is positive, values encoded longer ago are rejected with ErrExpired.
+func (c *Codec) Decode(name, encoded string, maxAge time.Duration) (string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", ErrInvalid
+	}
+	var payload []byte
+	for _, k := range c.keys {
+		if payload = c.open(k, name, data); payload != nil {
+			break
+		}
+	}
+	if len(payload) < 8 {
+		return "", ErrInvalid
+	}
+	issued := time.Unix(int64(binary.BigEndian.Uint64(payload)), 0)
+	if maxAge > 0 && time.Since(issued) > maxAge {
+		return "", ErrExpired
+	}
+	return string(payload[8:]), nil
+}
+
+// open verifies or decrypts data with one key, returning nil on failure.
+func (c *Codec) open(k key, name string, data []byte) []byte {
+	if c.encrypt {
+		ns := k.encrypt.NonceSize()
+		if len(data) < ns {
+			return nil
+		}
+		payload, err := k.encrypt.Open(nil, data[:ns], data[ns:], []byte(name))
+		if err != nil {
+			return nil
+		}
+		return payload
+	}
+	if len(data) < sha256.Size {
+		return nil
+	}
+	payload, mac := data[:len(data)-sha256.Size], data[len(data)-sha256.Size:]
+	if !hmac.Equal(mac, sign(k.sign, name, payload)) {
+		return nil
+	}
+	return payload
+}
+
+// sign computes the MAC of a payload for a cookie name.
+func sign(k []byte, name string, payload []byte) []byte {
+	mac := hmac.New(sha256.New, k)
+	mac.Write([]byte(name))
+	mac.Write([]byte{0})
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,74 @@
+package securecookie
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCodec(t *testing.T) {
+	oldSecret, newSecret := []byte("old-secret"), []byte("new-secret")
+	tests := []struct {
+		name    string
+		encrypt bool
+		encode  [][]byte // Secrets of the encoding codec.
+		decode  [][]byte // Secrets of the decoding codec.
+		tamper  func(string) string
+		decName string
+		want    error
+	}{
+		{"signed", false, [][]byte{newSecret}, [][]byte{newSecret}, nil, "", nil},
+		{"encrypted", true, [][]byte{newSecret}, [][]byte{newSecret}, nil, "", nil},
+		{"rotated secret", true, [][]byte{oldSecret}, [][]byte{newSecret, oldSecret}, nil, "", nil},
+		{"retired secret", false, [][]byte{oldSecret}, [][]byte{newSecret}, nil, "", ErrInvalid},
+		{"tampered", false, [][]byte{newSecret}, [][]byte{newSecret}, flipByte, "", ErrInvalid},
+		{"other cookie name", true, [][]byte{newSecret}, [][]byte{newSecret}, nil, "other", ErrInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enc, err := New(tt.encrypt, tt.encode...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			dec, err := New(tt.encrypt, tt.decode...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			v, err := enc.Encode("session", "value")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.tamper != nil {
+				v = tt.tamper(v)
+			}
+			name := "session"
+			if tt.decName != "" {
+				name = tt.decName
+			}
+			got, err := dec.Decode(name, v, time.Hour)
+			if !errors.Is(err, tt.want) || (err == nil && got != "value") {
+				t.Fatalf("Decode = %q, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+// flipByte changes one character of an encoded value.
+func flipByte(v string) string {
+	b := []byte(v)
+	if b[12] == 'A' {
+		b[12] = 'B'
+	} else {
+		b[12] = 'A'
+	}
+	return string(b)
+}
+
+func TestNewRejectsEmptySecrets(t *testing.T) {
+	if _, err := New(false); err == nil {
+		t.Fatal("New without secrets succeeded")
+	}
+	if _, err := New(false, []byte{}); err == nil {
+		t.Fatal("New with an empty secret succeeded")
+	}
+}
@@ -0,0 +1,390 @@
+package serializer
+
+import (
+	"context"
+	"encoding"
+	"encoding/json"
+	"encoding/xml"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Tag options recognised in `sail` struct tags:
+//
+//	sail:"exclude"             never serialize the field
+//	sail:"groups=admin owner"  serialize only for the listed groups
+//
+// Fields without a sail tag are always serialized.
+const tagName = "sail"
+
+var (
+	interfaceType = reflect.TypeOf((*interface{})(nil)).Elem()
+	xmlNameType   = reflect.TypeOf(xml.Name{})
+	jsonMarshaler = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	xmlMarshaler  = reflect.TypeOf((*xml.Marshaler)(nil)).Elem()
+	textMarshaler = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// fieldPlan maps a source field to a field of the projected struct.
+type fieldPlan struct {
+	index []int // Index of the field in the source struct.
+	plan  *plan
+}
+
+// plan describes how values of one type are projected for a set of groups.
+type plan struct {
+	typ      reflect.Type // Projected type.
+	identity bool         // Values can be used unchanged.
+	dynamic  bool         // Interface value projected at runtime.
+	fields   []fieldPlan  // Struct fields, in projected order.
+	elem     *plan        // Element plan for pointers, slices, arrays and maps.
+	xmlName  string       // Root element name added to projected root structs.
+}
+
+// planKey identifies a cached plan.
+type planKey struct {
+	typ    reflect.Type
+	groups string
+	root   bool
+}
+
+// maxPlans bounds the plan cache. Group sets may vary per request, so the
+// cache is cleared when it grows past this size.
+const maxPlans = 1024
+
+// Serializer projects values onto the fields visible to a set of groups.
+type Serializer struct {
+	cache sync.Map // planKey -> *plan
+	size  atomic.Int64
+}
+
+// New creates a Serializer.
+func New() *Serializer {
+	return &Serializer{}
+}
+
+// Serialize returns v with excluded fields removed and group-restricted
+// fields kept only when one of groups matches. Nested structs, pointers,
+// slices, arrays, maps and interface values are handled recursively.
+// Values of types without sail tags are returned unchanged.
+func (s *Serializer) Serialize(v interface{}, groups ...string) interface{} {
+	if v == nil {
+		return nil
+	}
+	return s.serialize(reflect.ValueOf(v), groups, true)
+}
+
+// serialize projects a value; root values get an XML element name.
+func (s *Serializer) serialize(val reflect.Value, groups []string, root bool) interface{} {
+	p := s.planFor(val.Type(), normalize(groups), root)
+	if p.identity {
+		return val.Interface()
+	}
+	return project(s, val, p, groups).Interface()
+}
+
+// normalize sorts and joins groups into a cache key.
+func normalize(groups []string) string {
+	sorted := append([]string(nil), groups...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, "\x00")
+}
+
+// planFor returns the cached plan for a type, building it if needed.
+func (s *Serializer) planFor(t reflect.Type, groups string, root bool) *plan {
+	key := planKey{typ: t, groups: groups, root: root}
+	if p, ok := s.cache.Load(key); ok {
+		return p.(*plan)
+	}
+	b := &builder{groups: strings.Split(groups, "\x00"), building: make(map[reflect.Type]bool)}
+	p := b.build(t)
+	if root && !p.identity && t.Kind() == reflect.Struct && !hasXMLName(t) {
+		p = withXMLName(p, t.Name())
+	}
+	if s.size.Add(1) > maxPlans {
+		s.cache.Clear()
+		s.size.Store(1)
+	}
+	s.cache.Store(key, p)
+	return p
+}
+
+// builder constructs plans for a single set of groups.
+type builder struct {
+	groups   []string
+	building map[reflect.Type]bool
+}
+
+// build creates the plan for t.
+func (b *builder) build(t reflect.Type) *plan {
+	if isOpaque(t) {
+		return &plan{typ: t, identity: true}
+	}
+	switch t.Kind() {
+	case reflect.Interface:
+		return &plan{typ: t, dynamic: true}
+	case reflect.Ptr:
+		elem := b.build(t.Elem())
+		if elem.identity {
+			return &plan{typ: t, identity: true}
+		}
+		return &plan{typ: reflect.PointerTo(elem.typ), elem: elem}
+	case reflect.Slice:
+		elem := b.build(t.Elem())
+		if elem.identity {
+			return &plan{typ: t, identity: true}
+		}
+		return &plan{typ: reflect.SliceOf(elem.typ), elem: elem}
+	case reflect.Array:
+		elem := b.build(t.Elem())
+		if elem.identity {
+			return &plan{typ: t, identity: true}
+		}
+		return &plan{typ: reflect.ArrayOf(t.Len(), elem.typ), elem: elem}
+	case reflect.Map:
+		elem := b.build(t.Elem())
+		if elem.identity {
+			return &plan{typ: t, identity: true}
+		}
+		return &plan{typ: reflect.MapOf(t.Key(), elem.typ), elem: elem}
+	case reflect.Struct:
+		return b.buildStruct(t)
+	}
+	return &plan{typ: t, identity: true}
+}
+
+// buildStruct creates the plan for a struct type. Recursive references are
+// projected as interface values because reflect cannot build recursive types.
+func (b *builder) buildStruct(t reflect.Type) *plan {
+	if b.building[t] {
+		return &plan{typ: interfaceType, dynamic: true}
+	}
+	b.building[t] = true
+	defer delete(b.building, t)
+
+	identity := true
+	var fields []fieldPlan
+	var structFields []reflect.StructField
+	taken := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		if sf := t.Field(i); !sf.Anonymous || hasJSONName(sf) {
+			taken[sf.Name] = true
+		}
+	}
+	var walk func(t reflect.Type, index []int, depth int)
+	walk = func(t reflect.Type, index []int, depth int) {
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			idx := append(append([]int(nil), index...), i)
+			if sf.Anonymous && !hasJSONName(sf) && !isOpaque(sf.Type) {
+				// Promote the fields of embedded structs into the projection,
+				// including unexported ones, as encoding/json does.
+				et := sf.Type
+				if et.Kind() == reflect.Ptr {
+					et = et.Elem()
+				}
+				if et.Kind() == reflect.Struct && !b.building[et] {
+					b.building[et] = true
+					walk(et, idx, depth+1)
+					delete(b.building, et)
+					continue
+				}
+			}
+			if !sf.IsExported() {
+				continue
+			}
+			if depth > 0 && taken[sf.Name] {
+				continue
+			}
+			if !b.visible(sf) {
+				identity = false
+				continue
+			}
+			fp := b.build(sf.Type)
+			if !fp.identity {
+				identity = false
+			}
+			taken[sf.Name] = true
+			fields = append(fields, fieldPlan{index: idx, plan: fp})
+			structFields = append(structFields, reflect.StructField{
+				Name: sf.Name,
+				Type: fp.typ,
+				Tag:  sf.Tag,
+			})
+		}
+	}
+	walk(t, nil, 0)
+	if identity {
+		return &plan{typ: t, identity: true}
+	}
+	return &plan{typ: reflect.StructOf(structFields), fields: fields}
+}
+
+// visible reports whether a field is serialized for the builder's groups.
+func (b *builder) visible(sf reflect.StructField) bool {
+	tag, ok := sf.Tag.Lookup(tagName)
+	if !ok {
+		return true
+	}
+	for _, opt := range strings.Split(tag, ",") {
+		opt = strings.TrimSpace(opt)
+		if opt == "exclude" {
+			return false
+		}
+		if list, ok := strings.CutPrefix(opt, "groups="); ok {
+			if !b.inGroups(list) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// inGroups reports whether any group in a space- or |-separated list is active.
+func (b *builder) inGroups(list string) bool {
+	for _, g := range strings.FieldsFunc(list, func(r rune) bool { return r == ' ' || r == '|' }) {
+		for _, active := range b.groups {
+			if g == active {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// project converts v according to p.
+func project(s *Serializer, v reflect.Value, p *plan, groups []string) reflect.Value {
+	if p.identity {
+		return v
+	}
+	if p.dynamic {
+		out := reflect.New(p.typ).Elem()
+		if v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				return out
+			}
+			v = v.Elem()
+		}
+		if v.IsValid() && v.CanInterface() {
+			if projected := s.serialize(v, groups, false); projected != nil {
+				out.Set(reflect.ValueOf(projected))
+			}
+		}
+		return out
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return reflect.Zero(p.typ)
+		}
+		out := reflect.New(p.elem.typ)
+		out.Elem().Set(project(s, v.Elem(), p.elem, groups))
+		return out
+	case reflect.Slice:
+		if v.IsNil() {
+			return reflect.Zero(p.typ)
+		}
+		out := reflect.MakeSlice(p.typ, v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(project(s, v.Index(i), p.elem, groups))
+		}
+		return out
+	case reflect.Array:
+		out := reflect.New(p.typ).Elem()
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(project(s, v.Index(i), p.elem, groups))
+		}
+		return out
+	case reflect.Map:
+		if v.IsNil() {
+			return reflect.Zero(p.typ)
+		}
+		out := reflect.MakeMapWithSize(p.typ, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			out.SetMapIndex(iter.Key(), project(s, iter.Value(), p.elem, groups))
+		}
+		return out
+	case reflect.Struct:
+		out := reflect.New(p.typ).Elem()
+		offset := 0
+		if p.xmlName != "" {
+			out.Field(0).Set(reflect.ValueOf(xml.Name{Local: p.xmlName}))
+			offset = 1
+		}
+		for i, fp := range p.fields {
+			fv, err := v.FieldByIndexErr(fp.index)
+			if err != nil {
+				// Field promoted through a nil embedded pointer.
+				continue
+			}
+			out.Field(i + offset).Set(project(s, fv, fp.plan, groups))
+		}
+		return out
+	}
+	return v
+}
+
+// withXMLName adds an XMLName field to a projected root struct so XML
+// encoding keeps the original element name.
+func withXMLName(p *plan, name string) *plan {
+	if name == "" {
+		return p
+	}
+	fields := []reflect.StructField{{
+		Name: "XMLName",
+		Type: xmlNameType,
+		Tag:  `json:"-"`,
+	}}
+	for i := 0; i < p.typ.NumField(); i++ {
+		fields = append(fields, p.typ.Field(i))
+	}
+	return &plan{typ: reflect.StructOf(fields), fields: p.fields, xmlName: name}
+}
+
+// hasXMLName reports whether a struct declares its own XMLName field.
+func hasXMLName(t reflect.Type) bool {
+	_, ok := t.FieldByName("XMLName")
+	return ok
+}
+
+// hasJSONName reports whether a struct field has an explicit json name.
+func hasJSONName(sf reflect.StructField) bool {
+	name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+	return name != ""
+}
+
+// isOpaque reports whether values of t marshal themselves and must not be projected.
+func isOpaque(t reflect.Type) bool {
+	for _, m := range []reflect.Type{jsonMarshaler, xmlMarshaler, textMarshaler} {
+		if t.Implements(m) || reflect.PointerTo(t).Implements(m) {
+			return true
+		}
+	}
+	return false
+}
+
+// groupsKey is the context key for the active serialization groups.
+type groupsKey struct{}
+
+// WithGroups returns a copy of ctx carrying the serialization groups.
+func WithGroups(ctx context.Context, groups ...string) context.Context {
+	return context.WithValue(ctx, groupsKey{}, groups)
+}
+
+// GroupsFromContext returns the serialization groups carried by ctx.
+func GroupsFromContext(ctx context.Context) []string {
+	groups, _ := ctx.Value(groupsKey{}).([]string)
+	return groups
+}
+
+// defaultSerializer backs the package-level helpers.
+var defaultSerializer = New()
+
+// Serialize projects v using the package-level Serializer.
+func Serialize(v interface{}, groups ...string) interface{} {
+	return defaultSerializer.Serialize(v, groups...)
+}
@@ -0,0 +1,93 @@
+package serializer
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+)
+
+type audit struct {
+	CreatedBy string `json:"createdBy"`
+	Secret    string `json:"secret" sail:"groups=admin"`
+}
+
+type plain struct {
+	audit
+	Name string `json:"name"`
+}
+
+type account struct {
+	ID       int       `json:"id"`
+	Email    string    `json:"email" sail:"groups=admin owner"`
+	Password string    `json:"password" sail:"exclude"`
+	Tags     []string  `json:"tags"`
+	Children []account `json:"children,omitempty"`
+	*audit
+}
+
+func TestSerialize(t *testing.T) {
+	acct := account{
+		ID:       1,
+		Email:    "a@example.com",
+		Password: "x",
+		Tags:     []string{"t"},
+		Children: []account{{ID: 2, Email: "b@example.com", Password: "y"}},
+		audit:    &audit{CreatedBy: "root", Secret: "s"},
+	}
+	tests := []struct {
+		name   string
+		value  interface{}
+		groups []string
+		want   string
+	}{
+		{"untagged unexported embed", plain{audit: audit{CreatedBy: "root", Secret: "s"}, Name: "n"}, []string{"admin"},
+			`{"createdBy":"root","secret":"s","name":"n"}`},
+		{"public", acct, nil,
+			`{"id":1,"tags":["t"],"children":[{"id":2,"tags":null,"createdBy":""}],"createdBy":"root"}`},
+		{"admin", acct, []string{"admin"},
+			`{"id":1,"email":"a@example.com","tags":["t"],"children":[{"id":2,"email":"b@example.com","tags":null,"createdBy":"","secret":""}],"createdBy":"root","secret":"s"}`},
+		{"slice", []account{acct}, []string{"owner"},
+			`[{"id":1,"email":"a@example.com","tags":["t"],"children":[{"id":2,"email":"b@example.com","tags":null,"createdBy":""}],"createdBy":"root"}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := json.Marshal(New().Serialize(tt.value, tt.groups...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tt.want {
+				t.Fatalf("got  %s\nwant %s", b, tt.want)
+			}
+		})
+	}
+}
+
+type stamp struct {
+	By string `json:"by"`
+}
+
+type note struct {
+	stamp
+	Text string `json:"text"`
+}
+
+func TestSerializeUntaggedMatchesJSON(t *testing.T) {
+	v := note{stamp: stamp{By: "root"}, Text: "n"}
+	got, _ := json.Marshal(New().Serialize(v))
+	direct, _ := json.Marshal(v)
+	if string(got) != string(direct) {
+		t.Fatalf("got %s, want %s", got, direct)
+	}
+}
+
+func TestPlanCacheBounded(t *testing.T) {
+	s := New()
+	for i := 0; i < maxPlans*2; i++ {
+		s.Serialize(account{ID: i}, strconv.Itoa(i))
+	}
+	n := 0
+	s.cache.Range(func(_, _ interface{}) bool { n++; return true })
+	if n > maxPlans {
+		t.Fatalf("cache holds %d plans, want at most %d", n, maxPlans)
+	}
+}
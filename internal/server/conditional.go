@@ -0,0 +1,95 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// FormatETag quotes an opaque tag and marks it weak if requested.
+// Tags that are already quoted are returned unchanged, and an empty tag,
+// meaning no current representation, stays empty.
+func FormatETag(tag string, weak bool) string {
+	if tag == "" {
+		return ""
+	}
+	if strings.HasPrefix(tag, `"`) || strings.HasPrefix(tag, `W/"`) {
+		return tag
+	}
+	etag := `"` + tag + `"`
+	if weak {
+		etag = "W/" + etag
+	}
+	return etag
+}
+
+// ETagListMatches reports whether an If-Match or If-None-Match header value
+// matches etag. "*" matches any current representation. Weak comparison
+// ignores the W/ prefix; strong comparison never matches weak tags.
+func ETagListMatches(header, etag string, weak bool) bool {
+	if etag == "" {
+		return false
+	}
+	header = strings.TrimSpace(header)
+	if header == "*" {
+		return true
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		if etagEqual(strings.TrimSpace(candidate), etag, weak) {
+			return true
+		}
+	}
+	return false
+}
+
+// etagEqual compares two entity tags.
+func etagEqual(a, b string, weak bool) bool {
+	if weak {
+		return strings.TrimPrefix(a, "W/") == strings.TrimPrefix(b, "W/")
+	}
+	return !strings.HasPrefix(a, "W/") && !strings.HasPrefix(b, "W/") && a == b
+}
+
+// CheckPreconditions evaluates If-Match, If-Unmodified-Since and, for
+// unsafe methods, If-None-Match against the current state of a resource.
+// An empty etag means the resource does not exist. It returns a 412
+// HTTPError when a precondition fails and nil otherwise.
+func CheckPreconditions(r *http.Request, etag string, lastModified time.Time) error {
+	failed := &HTTPError{
+		Status:  http.StatusPreconditionFailed,
+		Code:    "precondition_failed",
+		Message: "Precondition failed",
+	}
+	if im := r.Header.Get("If-Match"); im != "" {
+		if !ETagListMatches(im, etag, false) {
+			return failed
+		}
+	} else if ius := r.Header.Get("If-Unmodified-Since"); ius != "" && !lastModified.IsZero() {
+		if t, err := http.ParseTime(ius); err == nil && lastModified.Truncate(time.Second).After(t) {
+			return failed
+		}
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		if inm := r.Header.Get("If-None-Match"); inm != "" && ETagListMatches(inm, etag, true) {
+			return failed
+		}
+	}
+	return nil
+}
+
+// NotModified reports whether a GET or HEAD request's If-None-Match or
+// If-Modified-Since header shows the client's copy is current.
+func NotModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		return ETagListMatches(inm, etag, true)
+	}
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" && !lastModified.IsZero() {
+		if t, err := http.ParseTime(ims); err == nil {
+			return !lastModified.Truncate(time.Second).After(t)
+		}
+	}
+	return false
+}
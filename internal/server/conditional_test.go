@@ -0,0 +1,85 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestFormatETag(t *testing.T) {
+	tests := []struct {
+		tag  string
+		weak bool
+		want string
+	}{
+		{"", false, ""},
+		{"", true, ""},
+		{"abc", false, `"abc"`},
+		{"abc", true, `W/"abc"`},
+		{`"abc"`, true, `"abc"`},
+		{`W/"abc"`, false, `W/"abc"`},
+	}
+	for _, tt := range tests {
+		if got := FormatETag(tt.tag, tt.weak); got != tt.want {
+			t.Errorf("FormatETag(%q, %v) = %q, want %q", tt.tag, tt.weak, got, tt.want)
+		}
+	}
+}
+
+func TestCheckPreconditions(t *testing.T) {
+	modified := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name    string
+		method  string
+		header  string
+		value   string
+		etag    string
+		lastMod time.Time
+		fail    bool
+	}{
+		{"if-match matches", http.MethodPut, "If-Match", `"v1"`, `"v1"`, time.Time{}, false},
+		{"if-match stale", http.MethodPut, "If-Match", `"v0"`, `"v1"`, time.Time{}, true},
+		{"if-match weak never matches", http.MethodPut, "If-Match", `W/"v1"`, `W/"v1"`, time.Time{}, true},
+		{"if-match star on existing", http.MethodPut, "If-Match", "*", `"v1"`, time.Time{}, false},
+		{"if-match star on missing", http.MethodPut, "If-Match", "*", FormatETag("", false), time.Time{}, true},
+		{"if-none-match star on missing", http.MethodPut, "If-None-Match", "*", FormatETag("", false), time.Time{}, false},
+		{"if-none-match star on existing", http.MethodPut, "If-None-Match", "*", `"v1"`, time.Time{}, true},
+		{"if-none-match ignored on GET", http.MethodGet, "If-None-Match", `"v1"`, `"v1"`, time.Time{}, false},
+		{"if-unmodified-since passes", http.MethodPut, "If-Unmodified-Since", modified.Format(http.TimeFormat), "", modified, false},
+		{"if-unmodified-since fails", http.MethodPut, "If-Unmodified-Since", modified.Add(-time.Hour).Format(http.TimeFormat), "", modified, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/", nil)
+			r.Header.Set(tt.header, tt.value)
+			err := CheckPreconditions(r, tt.etag, tt.lastMod)
+			if (err != nil) != tt.fail {
+				t.Fatalf("CheckPreconditions = %v, want failure %v", err, tt.fail)
+			}
+		})
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		inm    string
+		want   bool
+	}{
+		{"matching weak", http.MethodGet, `W/"v1"`, true},
+		{"list", http.MethodGet, `"a", "v1"`, true},
+		{"different", http.MethodGet, `"v2"`, false},
+		{"unsafe method", http.MethodPost, `"v1"`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/", nil)
+			r.Header.Set("If-None-Match", tt.inm)
+			if got := NotModified(r, `"v1"`, time.Time{}); got != tt.want {
+				t.Fatalf("NotModified = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
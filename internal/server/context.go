@@ -0,0 +1,130 @@
+package server
+
+import (
+	"context"
+
+	"github.com/SailfinIO/sail/internal/core"
+	"github.com/SailfinIO/sail/internal/logger"
+)
+
+// Principal identifies the authenticated caller of a request.
+type Principal interface {
+	// Subject returns a stable identifier for the caller, e.g. a user ID.
+	Subject() string
+}
+
+// Context keys for request-scoped values.
+type (
+	loggerKey          struct{}
+	scopeKey           struct{}
+	principalKey       struct{}
+	principalHolderKey struct{}
+	requestIDKey       struct{}
+	cspNonceKey        struct{}
+	csrfTokenKey       struct{}
+)
+
+// WithLogger returns a copy of ctx carrying the request logger.
+func WithLogger(ctx context.Context, l logger.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// LoggerFromContext returns the request logger, or a default logger
+// when none has been attached. If the request has an ID, the logger
+// includes it as the request_id field.
+func LoggerFromContext(ctx context.Context) logger.Logger {
+	l, ok := ctx.Value(loggerKey{}).(logger.Logger)
+	if !ok {
+		l = logger.New()
+	}
+	if id := RequestIDFromContext(ctx); id != "" {
+		l = logger.WithField(l, "request_id", id)
+	}
+	return l
+}
+
+// WithRequestID returns a copy of ctx carrying the request ID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID, or an empty string.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// WithScope returns a copy of ctx carrying the request's DI scope.
+func WithScope(ctx context.Context, scope *core.Container) context.Context {
+	return context.WithValue(ctx, scopeKey{}, scope)
+}
+
+// ScopeFromContext returns the request's DI scope.
+func ScopeFromContext(ctx context.Context) (*core.Container, bool) {
+	scope, ok := ctx.Value(scopeKey{}).(*core.Container)
+	return scope, ok
+}
+
+// WithPrincipal returns a copy of ctx carrying the authenticated principal.
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	if holder, ok := ctx.Value(principalHolderKey{}).(*principalHolder); ok {
+		holder.principal = p
+	}
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext returns the authenticated principal, if any.
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	if p, ok := ctx.Value(principalKey{}).(Principal); ok {
+		return p, true
+	}
+	if holder, ok := ctx.Value(principalHolderKey{}).(*principalHolder); ok && holder.principal != nil {
+		return holder.principal, true
+	}
+	return nil, false
+}
+
+// principalHolder records the principal attached further down the chain.
+type principalHolder struct {
+	principal Principal
+}
+
+// TrackPrincipal returns a copy of ctx on which PrincipalFromContext also
+// sees principals attached later by inner handlers, e.g. for middleware
+// that logs the caller once the handler returns.
+func TrackPrincipal(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(principalHolderKey{}).(*principalHolder); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, principalHolderKey{}, &principalHolder{})
+}
+
+// WithCSPNonce returns a copy of ctx carrying the Content-Security-Policy nonce.
+func WithCSPNonce(ctx context.Context, nonce string) context.Context {
+	return context.WithValue(ctx, cspNonceKey{}, nonce)
+}
+
+// CSPNonceFromContext returns the Content-Security-Policy nonce, or an empty string.
+func CSPNonceFromContext(ctx context.Context) string {
+	nonce, _ := ctx.Value(cspNonceKey{}).(string)
+	return nonce
+}
+
+// WithCSRFToken returns a copy of ctx carrying the request's CSRF token.
+func WithCSRFToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, csrfTokenKey{}, func() string { return token })
+}
+
+// WithCSRFTokenFunc returns a copy of ctx whose CSRF token is obtained by
+// calling issue, for tokens that are only created when first asked for.
+func WithCSRFTokenFunc(ctx context.Context, issue func() string) context.Context {
+	return context.WithValue(ctx, csrfTokenKey{}, issue)
+}
+
+// CSRFTokenFromContext returns the request's CSRF token, or an empty string.
+func CSRFTokenFromContext(ctx context.Context) string {
+	if issue, ok := ctx.Value(csrfTokenKey{}).(func() string); ok {
+		return issue()
+	}
+	return ""
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// HTTPError is an error that carries an HTTP status code.
+// It is rendered by WriteError using the framework's JSON error format.
+type HTTPError struct {
+	Status  int         // HTTP status code sent to the client.
+	Code    string      // Machine-readable error code (optional).
+	Message string      // Human-readable message.
+	Details interface{} // Additional error details, e.g. per-field validation errors.
+}
+
+// NewHTTPError creates a new HTTPError with the given status and message.
+func NewHTTPError(status int, message string) *HTTPError {
+	return &HTTPError{
+		Status:  status,
+		Message: message,
+	}
+}
+
+// ErrUnauthenticated returns the 401 Unauthorized error for requests that
+// carry no credentials.
+func ErrUnauthenticated() *HTTPError {
+	return &HTTPError{
+		Status:  http.StatusUnauthorized,
+		Code:    "unauthorized",
+		Message: "Authentication required",
+	}
+}
+
+// Error implements the error interface.
+func (e *HTTPError) Error() string {
+	return e.Message
+}
+
+// errorBody is the JSON representation of an HTTPError.
+type errorBody struct {
+	StatusCode int         `json:"statusCode"`
+	Error      string      `json:"error"`
+	Code       string      `json:"code,omitempty"`
+	Message    string      `json:"message"`
+	Errors     interface{} `json:"errors,omitempty"`
+}
+
+// WriteError writes err to the response using the framework's JSON error format.
+// Errors that are not an *HTTPError are reported as a 500 without exposing their message.
+func WriteError(w http.ResponseWriter, err error) {
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) {
+		httpErr = NewHTTPError(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+	}
+	body := errorBody{
+		StatusCode: httpErr.Status,
+		Error:      http.StatusText(httpErr.Status),
+		Code:       httpErr.Code,
+		Message:    httpErr.Message,
+		Errors:     httpErr.Details,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(httpErr.Status)
+	json.NewEncoder(w).Encode(body)
+}
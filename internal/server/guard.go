@@ -0,0 +1,45 @@
+package server
+
+import "net/http"
+
+// Guard decides whether a request may reach its handler, similar to
+// NestJS's CanActivate. Returning an *HTTPError chooses the response
+// status, e.g. 401 or 403; other errors are reported as a 500.
+type Guard interface {
+	CanActivate(r *http.Request) error
+}
+
+// GuardFunc adapts a function to the Guard interface.
+type GuardFunc func(r *http.Request) error
+
+// CanActivate implements Guard.
+func (f GuardFunc) CanActivate(r *http.Request) error {
+	return f(r)
+}
+
+// UseGuards returns a middleware that runs guards in order and rejects the
+// request with the first error returned.
+func UseGuards(guards ...Guard) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, g := range guards {
+				if err := g.CanActivate(r); err != nil {
+					WriteError(w, err)
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Authenticated is a guard that rejects requests without a principal
+// with 401 Unauthorized.
+func Authenticated() Guard {
+	return GuardFunc(func(r *http.Request) error {
+		if _, ok := PrincipalFromContext(r.Context()); !ok {
+			return ErrUnauthenticated()
+		}
+		return nil
+	})
+}
@@ -3,12 +3,45 @@ package server
 import (
 	"context"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
+
+	"github.com/SailfinIO/sail/internal/logger"
 )
 
+// ServerOptions configures the underlying http.Server. A zero duration
+// means no timeout.
+type ServerOptions struct {
+	ReadTimeout       time.Duration // Maximum time to read a request, including the body.
+	ReadHeaderTimeout time.Duration // Maximum time to read request headers.
+	WriteTimeout      time.Duration // Maximum time to write a response.
+	IdleTimeout       time.Duration // Maximum time to wait for the next request on a keep-alive connection.
+	MaxHeaderBytes    int           // Maximum size of request headers; zero uses http.DefaultMaxHeaderBytes.
+	DisableKeepAlives bool          // Close connections after each request.
+	H2C               bool          // Serve HTTP/2 without TLS (prior knowledge), e.g. behind a proxy.
+}
+
+// DefaultServerOptions returns timeouts that protect against slow clients
+// (slowloris) while allowing long responses such as streams: headers must
+// arrive within 10s, the whole request within 60s and idle connections are
+// closed after 120s. There is no write timeout.
+func DefaultServerOptions() ServerOptions {
+	return ServerOptions{
+		ReadTimeout:       60 * time.Second,
+		ReadHeaderTimeout: 10 * time.Second,
+		IdleTimeout:       120 * time.Second,
+		MaxHeaderBytes:    http.DefaultMaxHeaderBytes,
+	}
+}
+
 // HTTPServer wraps the built-in net/http server.
 type HTTPServer struct {
-	server *http.Server
+	server   *http.Server
+	reloader *certReloader
+	logger   logger.Logger
+	stop     chan struct{}
 }
 
 // NewHTTPServer creates a new HTTPServer instance.
@@ -18,16 +51,96 @@ func NewHTTPServer(addr string, handler http.Handler) *HTTPServer {
 			Addr:    addr,
 			Handler: handler,
 		},
+		logger: logger.New(),
+		stop:   make(chan struct{}),
+	}
+}
+
+// Configure applies timeouts, limits and protocol settings. It must be
+// called before Start.
+func (s *HTTPServer) Configure(opts ServerOptions) {
+	s.server.ReadTimeout = opts.ReadTimeout
+	s.server.ReadHeaderTimeout = opts.ReadHeaderTimeout
+	s.server.WriteTimeout = opts.WriteTimeout
+	s.server.IdleTimeout = opts.IdleTimeout
+	s.server.MaxHeaderBytes = opts.MaxHeaderBytes
+	s.server.SetKeepAlivesEnabled(!opts.DisableKeepAlives)
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	protocols.SetHTTP2(true)
+	protocols.SetUnencryptedHTTP2(opts.H2C)
+	s.server.Protocols = protocols
+}
+
+// SetLogger sets the logger used to report certificate reloads.
+func (s *HTTPServer) SetLogger(l logger.Logger) {
+	s.logger = l
+}
+
+// EnableTLS makes Start serve HTTPS. The certificate, key and client CA
+// files are loaded now and reloaded when they change or when the process
+// receives SIGHUP, without restarting the server.
+func (s *HTTPServer) EnableTLS(cfg TLSConfig) error {
+	r, err := newCertReloader(cfg)
+	if err != nil {
+		return err
 	}
+	s.reloader = r
+	s.server.TLSConfig = r.tlsConfig()
+	return nil
 }
 
 // Start runs the HTTP server.
 func (s *HTTPServer) Start() error {
-	return s.server.ListenAndServe()
+	if s.reloader == nil {
+		return s.server.ListenAndServe()
+	}
+	go s.watchCertificates()
+	return s.server.ListenAndServeTLS("", "")
+}
+
+// watchCertificates reloads certificates on SIGHUP and, if configured,
+// when their files change, until the server shuts down.
+func (s *HTTPServer) watchCertificates() {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	var tick <-chan time.Time
+	if interval := s.reloader.cfg.ReloadInterval; interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-hup:
+			s.reloadCertificates("SIGHUP")
+		case <-tick:
+			if s.reloader.changed() {
+				s.reloadCertificates("file change")
+			}
+		}
+	}
+}
+
+// reloadCertificates reloads the certificates and logs the outcome.
+func (s *HTTPServer) reloadCertificates(reason string) {
+	if err := s.reloader.reload(); err != nil {
+		s.logger.Error("TLS certificate reload (" + reason + ") failed, keeping previous certificate: " + err.Error())
+		return
+	}
+	s.logger.Info("TLS certificates reloaded (" + reason + ")")
 }
 
 // Shutdown gracefully stops the HTTP server using the provided context.
 func (s *HTTPServer) Shutdown(ctx context.Context) error {
+	select {
+	case <-s.stop:
+	default:
+		close(s.stop)
+	}
 	return s.server.Shutdown(ctx)
 }
 
@@ -0,0 +1,37 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestHTTPServerConfigure(t *testing.T) {
+	s := NewHTTPServer(":0", http.NotFoundHandler())
+	s.Configure(ServerOptions{
+		ReadTimeout:       time.Second,
+		ReadHeaderTimeout: 2 * time.Second,
+		WriteTimeout:      3 * time.Second,
+		IdleTimeout:       4 * time.Second,
+		MaxHeaderBytes:    5000,
+		H2C:               true,
+	})
+	hs := s.server
+	if hs.ReadTimeout != time.Second || hs.ReadHeaderTimeout != 2*time.Second ||
+		hs.WriteTimeout != 3*time.Second || hs.IdleTimeout != 4*time.Second || hs.MaxHeaderBytes != 5000 {
+		t.Fatalf("http.Server = %+v", hs)
+	}
+	if !hs.Protocols.UnencryptedHTTP2() || !hs.Protocols.HTTP1() {
+		t.Fatalf("protocols = %v", hs.Protocols)
+	}
+}
+
+func TestDefaultServerOptions(t *testing.T) {
+	opts := DefaultServerOptions()
+	if opts.ReadHeaderTimeout <= 0 || opts.ReadTimeout <= 0 || opts.IdleTimeout <= 0 {
+		t.Fatalf("DefaultServerOptions leaves a timeout disabled: %+v", opts)
+	}
+	if opts.WriteTimeout != 0 {
+		t.Fatalf("DefaultServerOptions sets a write timeout: %s", opts.WriteTimeout)
+	}
+}
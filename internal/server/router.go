@@ -1,39 +1,199 @@
 package server
 
-import "net/http"
+import (
+	"context"
+	"net/http"
+	"strings"
+)
 
 // Middleware is a function that wraps an http.Handler.
 type Middleware func(http.Handler) http.Handler
 
+// Route describes a registered route and the metadata attached to it.
+type Route struct {
+	Pattern  string
+	Metadata map[string]interface{}
+}
+
+// RouteOption configures a Route at registration time.
+type RouteOption func(*Route)
+
+// SetMetadata attaches a metadata value to a route, similar to NestJS's
+// SetMetadata decorator. Middleware and handlers read it with RouteFromContext.
+func SetMetadata(key string, value interface{}) RouteOption {
+	return func(route *Route) {
+		route.Metadata[key] = value
+	}
+}
+
+// Context keys for the matched Route.
+type (
+	routeKey       struct{}
+	routeHolderKey struct{}
+)
+
+// routeHolder is filled in by the matched route so that global middleware,
+// which runs before routing, can see the route once the handler returns.
+// It also records the Router serving the request and the matched request,
+// which carries the path wildcard values.
+type routeHolder struct {
+	router  *Router
+	route   *Route
+	matched *http.Request
+}
+
+// RouteFromContext returns the Route matched for the current request.
+func RouteFromContext(ctx context.Context) (*Route, bool) {
+	if route, ok := ctx.Value(routeKey{}).(*Route); ok {
+		return route, true
+	}
+	if holder, ok := ctx.Value(routeHolderKey{}).(*routeHolder); ok && holder.route != nil {
+		return holder.route, true
+	}
+	return nil, false
+}
+
+// RouterFromContext returns the Router serving the current request.
+// It is available to global middleware, before routing.
+func RouterFromContext(ctx context.Context) (*Router, bool) {
+	if holder, ok := ctx.Value(routeHolderKey{}).(*routeHolder); ok {
+		return holder.router, true
+	}
+	return nil, false
+}
+
+// PathValue returns the value of the named path wildcard of the route
+// matched for r. Unlike r.PathValue it also works in global middleware once
+// the route handler has run.
+func PathValue(r *http.Request, name string) string {
+	if r.Pattern != "" {
+		return r.PathValue(name)
+	}
+	if holder, ok := r.Context().Value(routeHolderKey{}).(*routeHolder); ok && holder.matched != nil {
+		return holder.matched.PathValue(name)
+	}
+	return ""
+}
+
 // Router provides minimal routing functionality with middleware support.
 type Router struct {
 	mux         *http.ServeMux
 	middlewares []Middleware
+	globals     []Middleware
+	handler     http.Handler // mux wrapped by global middleware.
 }
 
 // NewRouter returns a new Router instance.
 func NewRouter() *Router {
+	mux := http.NewServeMux()
 	return &Router{
-		mux: http.NewServeMux(),
+		mux:     mux,
+		handler: mux,
 	}
 }
 
 // Use adds a middleware to the Router.
+// It applies to routes registered after the call.
 func (r *Router) Use(mw Middleware) {
 	r.middlewares = append(r.middlewares, mw)
 }
 
+// UseGlobal adds a middleware that runs for every request before routing,
+// including requests that match no route (e.g. CORS preflights).
+func (r *Router) UseGlobal(mw Middleware) {
+	r.globals = append(r.globals, mw)
+	var handler http.Handler = r.mux
+	for i := len(r.globals) - 1; i >= 0; i-- {
+		handler = r.globals[i](handler)
+	}
+	r.handler = handler
+}
+
+// Handler returns the route handler and pattern that would serve req.
+// The pattern is empty when no route matches.
+func (r *Router) Handler(req *http.Request) (http.Handler, string) {
+	return r.mux.Handler(req)
+}
+
 // Handle registers a new route with the given pattern and handler.
-func (r *Router) Handle(pattern string, handler http.Handler) {
+// Route options attach metadata that is visible to the middleware chain.
+func (r *Router) Handle(pattern string, handler http.Handler, opts ...RouteOption) {
+	route := &Route{Pattern: pattern, Metadata: make(map[string]interface{})}
+	for _, opt := range opts {
+		opt(route)
+	}
 	// Apply middleware chain in reverse order
 	finalHandler := handler
 	for i := len(r.middlewares) - 1; i >= 0; i-- {
 		finalHandler = r.middlewares[i](finalHandler)
 	}
-	r.mux.Handle(pattern, finalHandler)
+	r.mux.Handle(pattern, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if holder, ok := req.Context().Value(routeHolderKey{}).(*routeHolder); ok {
+			holder.route = route
+			holder.matched = req
+		}
+		finalHandler.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), routeKey{}, route)))
+	}))
 }
 
 // ServeHTTP makes Router implement the http.Handler interface.
 func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	r.mux.ServeHTTP(w, req)
+	req = req.WithContext(context.WithValue(req.Context(), routeHolderKey{}, &routeHolder{router: r}))
+	r.handler.ServeHTTP(w, req)
+}
+
+// Group registers routes under a common path prefix with additional
+// middleware that applies only to those routes.
+type Group struct {
+	router      *Router
+	prefix      string
+	middlewares []Middleware
+}
+
+// Group returns a route group whose patterns are prefixed with prefix.
+// The group's middleware runs after the router's middleware.
+func (r *Router) Group(prefix string, mws ...Middleware) *Group {
+	return &Group{
+		router:      r,
+		prefix:      strings.TrimSuffix(prefix, "/"),
+		middlewares: mws,
+	}
+}
+
+// Use adds a middleware to the group.
+// It applies to routes registered on the group after the call.
+func (g *Group) Use(mw Middleware) {
+	g.middlewares = append(g.middlewares, mw)
+}
+
+// Group returns a nested group that inherits this group's prefix and middleware.
+func (g *Group) Group(prefix string, mws ...Middleware) *Group {
+	return &Group{
+		router:      g.router,
+		prefix:      g.prefix + strings.TrimSuffix(prefix, "/"),
+		middlewares: append(append([]Middleware(nil), g.middlewares...), mws...),
+	}
+}
+
+// Handle registers a route on the group. The pattern may include a method,
+// e.g. "GET /items/{id}", and is registered as "GET <prefix>/items/{id}".
+func (g *Group) Handle(pattern string, handler http.Handler, opts ...RouteOption) {
+	finalHandler := handler
+	for i := len(g.middlewares) - 1; i >= 0; i-- {
+		finalHandler = g.middlewares[i](finalHandler)
+	}
+	g.router.Handle(g.prefixPattern(pattern), finalHandler, opts...)
+}
+
+// prefixPattern inserts the group prefix before the path of a ServeMux pattern.
+func (g *Group) prefixPattern(pattern string) string {
+	method := ""
+	if i := strings.IndexByte(pattern, ' '); i >= 0 {
+		method, pattern = pattern[:i+1], strings.TrimLeft(pattern[i+1:], " ")
+	}
+	host := ""
+	if i := strings.IndexByte(pattern, '/'); i > 0 {
+		host, pattern = pattern[:i], pattern[i:]
+	}
+	return method + host + g.prefix + pattern
 }
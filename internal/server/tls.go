@@ -0,0 +1,198 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TLSConfig describes how HTTPServer serves HTTPS.
+type TLSConfig struct {
+	CertFile string // PEM certificate chain.
+	KeyFile  string // PEM private key.
+	// MinVersion defaults to TLS 1.2.
+	MinVersion uint16
+	// CipherSuites restricts the TLS 1.2 cipher suites; TLS 1.3 suites are
+	// not configurable. Defaults to Go's secure defaults.
+	CipherSuites []uint16
+	// ClientCAFile enables mutual TLS: client certificates must chain to
+	// a CA in this PEM file.
+	ClientCAFile string
+	// ClientAuth sets the client certificate policy. When nil it defaults
+	// to tls.RequireAndVerifyClientCert if ClientCAFile is set, and to
+	// tls.NoClientCert otherwise.
+	ClientAuth *tls.ClientAuthType
+	// ReloadInterval is how often the files are checked for changes.
+	// Zero disables polling; certificates are still reloaded on SIGHUP.
+	ReloadInterval time.Duration
+}
+
+// ParseTLSVersion parses "1.0", "1.1", "1.2" or "1.3".
+func ParseTLSVersion(s string) (uint16, error) {
+	switch strings.TrimPrefix(strings.ToLower(strings.TrimSpace(s)), "tls") {
+	case "1.0", "10":
+		return tls.VersionTLS10, nil
+	case "1.1", "11":
+		return tls.VersionTLS11, nil
+	case "1.2", "12":
+		return tls.VersionTLS12, nil
+	case "1.3", "13":
+		return tls.VersionTLS13, nil
+	}
+	return 0, fmt.Errorf("unknown TLS version %q", s)
+}
+
+// ParseCipherSuites parses a comma-separated list of cipher suite names,
+// e.g. "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256". Insecure suites are rejected.
+func ParseCipherSuites(s string) ([]uint16, error) {
+	byName := make(map[string]uint16)
+	for _, cs := range tls.CipherSuites() {
+		byName[cs.Name] = cs.ID
+	}
+	var ids []uint16
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		id, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown or insecure cipher suite %q", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// ParseClientAuth parses "none", "request", "require", "verify_if_given"
+// or "require_and_verify".
+func ParseClientAuth(s string) (tls.ClientAuthType, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "none":
+		return tls.NoClientCert, nil
+	case "request":
+		return tls.RequestClientCert, nil
+	case "require":
+		return tls.RequireAnyClientCert, nil
+	case "verify_if_given":
+		return tls.VerifyClientCertIfGiven, nil
+	case "require_and_verify", "":
+		return tls.RequireAndVerifyClientCert, nil
+	}
+	return 0, fmt.Errorf("unknown client auth mode %q", s)
+}
+
+// certReloader serves the current certificate and client CA pool and
+// reloads them when the files change.
+type certReloader struct {
+	cfg     TLSConfig
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	pool    *x509.CertPool
+	modTime map[string]time.Time
+}
+
+// newCertReloader loads the configured files.
+func newCertReloader(cfg TLSConfig) (*certReloader, error) {
+	r := &certReloader{cfg: cfg}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// files returns the files the reloader watches.
+func (r *certReloader) files() []string {
+	files := []string{r.cfg.CertFile, r.cfg.KeyFile}
+	if r.cfg.ClientCAFile != "" {
+		files = append(files, r.cfg.ClientCAFile)
+	}
+	return files
+}
+
+// changed reports whether any watched file has a new modification time.
+func (r *certReloader) changed() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, f := range r.files() {
+		info, err := os.Stat(f)
+		if err != nil {
+			continue
+		}
+		if !info.ModTime().Equal(r.modTime[f]) {
+			return true
+		}
+	}
+	return false
+}
+
+// reload reads the certificate, key and client CAs. On error the
+// previous certificate stays in use.
+func (r *certReloader) reload() error {
+	modTime := make(map[string]time.Time)
+	for _, f := range r.files() {
+		info, err := os.Stat(f)
+		if err != nil {
+			return err
+		}
+		modTime[f] = info.ModTime()
+	}
+	cert, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+	if err != nil {
+		return err
+	}
+	var pool *x509.CertPool
+	if r.cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.cfg.ClientCAFile)
+		if err != nil {
+			return err
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return errors.New("no certificates found in " + r.cfg.ClientCAFile)
+		}
+	}
+	r.mu.Lock()
+	r.cert, r.pool, r.modTime = &cert, pool, modTime
+	r.mu.Unlock()
+	return nil
+}
+
+// tlsConfig builds a tls.Config that always uses the current certificate
+// and client CA pool.
+func (r *certReloader) tlsConfig() *tls.Config {
+	base := &tls.Config{
+		MinVersion:   r.cfg.MinVersion,
+		CipherSuites: r.cfg.CipherSuites,
+		NextProtos:   []string{"h2", "http/1.1"},
+	}
+	if base.MinVersion == 0 {
+		base.MinVersion = tls.VersionTLS12
+	}
+	if r.cfg.ClientAuth != nil {
+		base.ClientAuth = *r.cfg.ClientAuth
+	} else if r.cfg.ClientCAFile != "" {
+		base.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	// The outer config mirrors the policy so that code inspecting
+	// http.Server.TLSConfig sees it; handshakes use GetConfigForClient.
+	return &tls.Config{
+		MinVersion:   base.MinVersion,
+		CipherSuites: base.CipherSuites,
+		ClientAuth:   base.ClientAuth,
+		NextProtos:   base.NextProtos,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+			cfg := base.Clone()
+			cfg.Certificates = []tls.Certificate{*r.cert}
+			cfg.ClientCAs = r.pool
+			return cfg, nil
+		},
+	}
+}
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeTestCert writes a self-signed certificate and its key to dir.
+func writeTestCert(t *testing.T, dir string) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)
+	return certFile, keyFile
+}
+
+func TestCertReloaderTLSConfig(t *testing.T) {
+	certFile, keyFile := writeTestCert(t, t.TempDir())
+	authType := func(a tls.ClientAuthType) *tls.ClientAuthType { return &a }
+	suites := []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256}
+	tests := []struct {
+		name     string
+		clientCA bool
+		auth     *tls.ClientAuthType
+		want     tls.ClientAuthType
+	}{
+		{"no client CA", false, nil, tls.NoClientCert},
+		{"client CA defaults to verify", true, nil, tls.RequireAndVerifyClientCert},
+		{"explicit none with client CA", true, authType(tls.NoClientCert), tls.NoClientCert},
+		{"verify if given", true, authType(tls.VerifyClientCertIfGiven), tls.VerifyClientCertIfGiven},
+		{"request without client CA", false, authType(tls.RequestClientCert), tls.RequestClientCert},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := TLSConfig{CertFile: certFile, KeyFile: keyFile, ClientAuth: tt.auth, CipherSuites: suites}
+			if tt.clientCA {
+				cfg.ClientCAFile = certFile
+			}
+			r, err := newCertReloader(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			outer := r.tlsConfig()
+			inner, err := outer.GetConfigForClient(&tls.ClientHelloInfo{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for name, c := range map[string]*tls.Config{"outer": outer, "per-client": inner} {
+				if c.ClientAuth != tt.want {
+					t.Fatalf("%s ClientAuth = %v, want %v", name, c.ClientAuth, tt.want)
+				}
+				if len(c.CipherSuites) != 1 || c.CipherSuites[0] != suites[0] {
+					t.Fatalf("%s CipherSuites = %v", name, c.CipherSuites)
+				}
+				if c.MinVersion != tls.VersionTLS12 {
+					t.Fatalf("%s MinVersion = %x", name, c.MinVersion)
+				}
+			}
+			if len(inner.Certificates) != 1 || (inner.ClientCAs != nil) != tt.clientCA {
+				t.Fatalf("per-client config has %d certificates, client CAs %v", len(inner.Certificates), inner.ClientCAs != nil)
+			}
+		})
+	}
+}
+
+func TestParseClientAuth(t *testing.T) {
+	tests := []struct {
+		in   string
+		want tls.ClientAuthType
+		fail bool
+	}{
+		{"none", tls.NoClientCert, false},
+		{"request", tls.RequestClientCert, false},
+		{"require", tls.RequireAnyClientCert, false},
+		{"verify_if_given", tls.VerifyClientCertIfGiven, false},
+		{"REQUIRE_AND_VERIFY", tls.RequireAndVerifyClientCert, false},
+		{"sometimes", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseClientAuth(tt.in)
+			if (err != nil) != tt.fail || (!tt.fail && got != tt.want) {
+				t.Fatalf("ParseClientAuth(%q) = %v, %v", tt.in, got, err)
+			}
+		})
+	}
+}
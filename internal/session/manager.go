@@ -0,0 +1,94 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// touchInterval is how often an unmodified session is saved to extend its
+// idle timeout.
+const touchInterval = time.Minute
+
+// Manager loads and saves sessions, enforcing idle and absolute timeouts.
+type Manager struct {
+	Store           Store
+	IdleTimeout     time.Duration // Expire sessions unused for this long.
+	AbsoluteTimeout time.Duration // Expire sessions this long after creation.
+}
+
+// Load returns the session with the given ID, or a new session if the ID
+// is empty, unknown or expired.
+func (m *Manager) Load(ctx context.Context, id string) (*Session, error) {
+	if id == "" {
+		return New(), nil
+	}
+	data, ok, err := m.Store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return New(), nil
+	}
+	s, err := decode(id, data)
+	if err != nil {
+		m.Store.Delete(ctx, id)
+		return New(), nil
+	}
+	now := time.Now()
+	if now.Sub(s.rec.LastSeen) > m.IdleTimeout || now.Sub(s.rec.CreatedAt) > m.AbsoluteTimeout {
+		m.Store.Delete(ctx, id)
+		return New(), nil
+	}
+	return s, nil
+}
+
+// Commit persists the changes made to a session during a request. It
+// returns the ID the session cookie must carry and whether the cookie has
+// to be written; an empty ID means the cookie must be cleared.
+func (m *Manager) Commit(ctx context.Context, s *Session) (id string, writeCookie bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, old := range s.previousIDs {
+		if err := m.Store.Delete(ctx, old); err != nil {
+			return "", false, err
+		}
+	}
+	s.previousIDs = nil
+	now := time.Now()
+	if !s.modified && s.isNew {
+		// Empty new sessions are not stored. A client whose session was
+		// destroyed has its cookie cleared.
+		writeCookie = s.loadedID != ""
+		s.loadedID = ""
+		return "", writeCookie, nil
+	}
+	if !s.modified && now.Sub(s.rec.LastSeen) < touchInterval {
+		return s.id, false, nil
+	}
+	ttl := m.IdleTimeout
+	if remaining := s.rec.CreatedAt.Add(m.AbsoluteTimeout).Sub(now); remaining < ttl {
+		ttl = remaining
+	}
+	if ttl <= 0 {
+		if err := m.Store.Delete(ctx, s.id); err != nil {
+			return "", false, err
+		}
+		writeCookie = s.loadedID != ""
+		s.loadedID = ""
+		return "", writeCookie, nil
+	}
+	s.rec.LastSeen = now
+	data, err := json.Marshal(s.rec)
+	if err != nil {
+		return "", false, err
+	}
+	if err := m.Store.Set(ctx, s.id, data, ttl); err != nil {
+		return "", false, err
+	}
+	writeCookie = s.id != s.loadedID
+	s.loadedID = s.id
+	s.isNew = false
+	s.modified = false
+	return s.id, writeCookie, nil
+}
@@ -0,0 +1,80 @@
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestManagerCommit(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name        string
+		change      func(s *Session)
+		writeCookie bool
+		clearCookie bool
+		oldKept     bool
+		value       string // Value of "k" in the committed session.
+	}{
+		{"unchanged", func(s *Session) {}, false, false, true, "old"},
+		{"set", func(s *Session) { s.Set("k", "new") }, false, false, true, "new"},
+		{"regenerate", func(s *Session) { s.Regenerate() }, true, false, false, "old"},
+		{"destroy", func(s *Session) { s.Destroy() }, true, true, false, ""},
+		{"set after destroy", func(s *Session) {
+			s.Destroy()
+			s.Set("k", "fresh")
+		}, true, false, false, "fresh"},
+		{"flash after destroy", func(s *Session) {
+			s.Destroy()
+			s.Flash("info", "logged out")
+		}, true, false, false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Manager{Store: NewMemoryStore(), IdleTimeout: time.Hour, AbsoluteTimeout: time.Hour}
+			s, _ := m.Load(ctx, "")
+			s.Set("k", "old")
+			oldID, _, err := m.Commit(ctx, s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			s, err = m.Load(ctx, oldID)
+			if err != nil || s.ID() != oldID {
+				t.Fatalf("Load = %v, %v", s.ID(), err)
+			}
+			tt.change(s)
+			id, write, err := m.Commit(ctx, s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if write != tt.writeCookie || (id == "") != tt.clearCookie {
+				t.Fatalf("Commit = %q, %v", id, write)
+			}
+			if _, ok, _ := m.Store.Get(ctx, oldID); ok != tt.oldKept {
+				t.Fatalf("old session stored = %v, want %v", ok, tt.oldKept)
+			}
+			if id == "" {
+				return
+			}
+			loaded, _ := m.Load(ctx, id)
+			if loaded.ID() != id || loaded.GetString("k") != tt.value {
+				t.Fatalf("loaded %q with k = %q, want %q with %q", loaded.ID(), loaded.GetString("k"), id, tt.value)
+			}
+		})
+	}
+}
+
+func TestManagerLoadExpired(t *testing.T) {
+	ctx := context.Background()
+	m := &Manager{Store: NewMemoryStore(), IdleTimeout: time.Hour, AbsoluteTimeout: time.Minute}
+	s := New()
+	s.Set("k", "v")
+	s.rec.CreatedAt = time.Now().Add(-30 * time.Minute)
+	id, _, _ := m.Commit(ctx, s)
+	if id != "" {
+		t.Fatalf("session past its absolute timeout committed as %q", id)
+	}
+	if loaded, _ := m.Load(ctx, s.ID()); loaded.GetString("k") != "" {
+		t.Fatal("expired session loaded")
+	}
+}
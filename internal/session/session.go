@@ -0,0 +1,181 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// record is the stored form of a session.
+type record struct {
+	Values    map[string]interface{}   `json:"values,omitempty"`
+	Flashes   map[string][]interface{} `json:"flashes,omitempty"`
+	CreatedAt time.Time                `json:"createdAt"`
+	LastSeen  time.Time                `json:"lastSeen"`
+}
+
+// Session holds per-client state kept in a Store. Values must be
+// JSON-serializable; numbers read back from the store are float64.
+// A Session is safe for concurrent use.
+type Session struct {
+	mu          sync.Mutex
+	id          string
+	rec         record
+	isNew       bool
+	modified    bool
+	loadedID    string   // ID the client sent, empty for new sessions.
+	previousIDs []string // IDs replaced by Regenerate, to delete on save.
+}
+
+// New creates an empty session with a fresh ID.
+func New() *Session {
+	now := time.Now()
+	return &Session{
+		id:    NewID(),
+		rec:   record{CreatedAt: now, LastSeen: now},
+		isNew: true,
+	}
+}
+
+// decode restores a session from its stored form.
+func decode(id string, data []byte) (*Session, error) {
+	s := &Session{id: id, loadedID: id}
+	if err := json.Unmarshal(data, &s.rec); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// NewID returns a random session ID.
+func NewID() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// ID returns the session ID.
+func (s *Session) ID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.id
+}
+
+// Get returns a value, or nil if it is not set.
+func (s *Session) Get(key string) interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rec.Values[key]
+}
+
+// GetString returns a string value, or an empty string.
+func (s *Session) GetString(key string) string {
+	v, _ := s.Get(key).(string)
+	return v
+}
+
+// Set stores a value.
+func (s *Session) Set(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.rec.Values == nil {
+		s.rec.Values = make(map[string]interface{})
+	}
+	s.rec.Values[key] = value
+	s.modified = true
+}
+
+// Delete removes a value.
+func (s *Session) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.rec.Values, key)
+	s.modified = true
+}
+
+// Clear removes all values and flash messages.
+func (s *Session) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rec.Values = nil
+	s.rec.Flashes = nil
+	s.modified = true
+}
+
+// Flash adds a message of the given kind (e.g. "error") that is kept
+// until it is read with Flashes, typically on the next request.
+func (s *Session) Flash(kind string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.rec.Flashes == nil {
+		s.rec.Flashes = make(map[string][]interface{})
+	}
+	s.rec.Flashes[kind] = append(s.rec.Flashes[kind], value)
+	s.modified = true
+}
+
+// Flashes returns and removes the flash messages of a kind.
+func (s *Session) Flashes(kind string) []interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	flashes, ok := s.rec.Flashes[kind]
+	if ok {
+		delete(s.rec.Flashes, kind)
+		s.modified = true
+	}
+	return flashes
+}
+
+// Regenerate gives the session a new ID while keeping its values. Call
+// it when privileges change, e.g. on login, to prevent session fixation.
+// The absolute timeout restarts.
+func (s *Session) Regenerate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.isNew {
+		s.previousIDs = append(s.previousIDs, s.id)
+	}
+	s.id = NewID()
+	s.rec.CreatedAt = time.Now()
+	s.modified = true
+}
+
+// Destroy deletes the session from the store and clears its cookie, e.g.
+// on logout. The session continues empty under a new ID, so values and
+// flash messages set afterwards are saved to a fresh session.
+func (s *Session) Destroy() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.isNew {
+		s.previousIDs = append(s.previousIDs, s.id)
+	}
+	now := time.Now()
+	s.id = NewID()
+	s.rec = record{CreatedAt: now, LastSeen: now}
+	s.isNew = true
+	s.modified = false
+}
+
+// CreatedAt returns when the session was created or last regenerated.
+func (s *Session) CreatedAt() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rec.CreatedAt
+}
+
+// contextKey is the context key for the request's session.
+type contextKey struct{}
+
+// WithSession returns a copy of ctx carrying the session.
+func WithSession(ctx context.Context, s *Session) context.Context {
+	return context.WithValue(ctx, contextKey{}, s)
+}
+
+// FromContext returns the request's session, or nil when the session
+// middleware is not installed.
+func FromContext(ctx context.Context) *Session {
+	s, _ := ctx.Value(contextKey{}).(*Session)
+	return s
+}
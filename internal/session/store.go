@@ -0,0 +1,197 @@
+package session
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Store persists encoded sessions. Implementations backed by a shared
+// service (e.g. Redis) let several instances share sessions.
+type Store interface {
+	// Get returns the data of a session, or ok == false if it does not
+	// exist or has expired.
+	Get(ctx context.Context, id string) (data []byte, ok bool, err error)
+	// Set saves a session for ttl.
+	Set(ctx context.Context, id string, data []byte, ttl time.Duration) error
+	// Delete removes a session.
+	Delete(ctx context.Context, id string) error
+}
+
+// memoryEntry is a stored session with its expiry time.
+type memoryEntry struct {
+	data    []byte
+	expires time.Time
+}
+
+// MemoryStore is an in-process Store. Expired sessions are evicted once
+// per minute.
+type MemoryStore struct {
+	mu        sync.Mutex
+	entries   map[string]memoryEntry
+	lastSweep time.Time
+}
+
+// NewMemoryStore creates an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]memoryEntry)}
+}
+
+// Get implements Store.
+func (s *MemoryStore) Get(_ context.Context, id string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[id]
+	if !ok || time.Now().After(e.expires) {
+		return nil, false, nil
+	}
+	return e.data, true, nil
+}
+
+// Set implements Store.
+func (s *MemoryStore) Set(_ context.Context, id string, data []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if now.Sub(s.lastSweep) > time.Minute {
+		s.lastSweep = now
+		for key, e := range s.entries {
+			if now.After(e.expires) {
+				delete(s.entries, key)
+			}
+		}
+	}
+	s.entries[id] = memoryEntry{data: data, expires: now.Add(ttl)}
+	return nil
+}
+
+// Delete implements Store.
+func (s *MemoryStore) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, id)
+	return nil
+}
+
+// Len returns the number of stored sessions.
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+// fileEntry is the on-disk form of a session.
+type fileEntry struct {
+	Expires time.Time `json:"expires"`
+	Data    []byte    `json:"data"`
+}
+
+// FileStore keeps each session in a file in a directory. Expired files
+// are removed by Cleanup and when they are read.
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore creates a store in dir, creating the directory if needed.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// path returns the file of a session. IDs are base64url encoded, so they
+// cannot escape the directory.
+func (s *FileStore) path(id string) (string, error) {
+	if _, err := base64.RawURLEncoding.DecodeString(id); err != nil || id == "" {
+		return "", errors.New("session: invalid session ID")
+	}
+	return filepath.Join(s.dir, id+".json"), nil
+}
+
+// Get implements Store.
+func (s *FileStore) Get(_ context.Context, id string) ([]byte, bool, error) {
+	path, err := s.path(id)
+	if err != nil {
+		return nil, false, nil
+	}
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	var e fileEntry
+	if err := json.Unmarshal(raw, &e); err != nil {
+		return nil, false, err
+	}
+	if time.Now().After(e.Expires) {
+		os.Remove(path)
+		return nil, false, nil
+	}
+	return e.Data, true, nil
+}
+
+// Set implements Store. Files are replaced atomically.
+func (s *FileStore) Set(_ context.Context, id string, data []byte, ttl time.Duration) error {
+	path, err := s.path(id)
+	if err != nil {
+		return err
+	}
+	raw, err := json.Marshal(fileEntry{Expires: time.Now().Add(ttl), Data: data})
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(s.dir, ".session-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Delete implements Store.
+func (s *FileStore) Delete(_ context.Context, id string) error {
+	path, err := s.path(id)
+	if err != nil {
+		return nil
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Cleanup removes expired session files.
+func (s *FileStore) Cleanup() error {
+	files, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, path := range files {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var e fileEntry
+		if json.Unmarshal(raw, &e) != nil || now.After(e.Expires) {
+			os.Remove(path)
+		}
+	}
+	return nil
+}
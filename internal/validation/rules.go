@@ -0,0 +1,326 @@
+package validation
+
+import (
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// paramKind describes the argument a rule expects in a validate tag.
+type paramKind int
+
+const (
+	paramAny    paramKind = iota // Not checked.
+	paramNone                    // No argument.
+	paramNumber                  // A number, e.g. min=1.
+	paramList                    // A non-empty list, e.g. oneof=a b.
+	paramField                   // A sibling field, e.g. eqfield=Password.
+)
+
+// builtin pairs a rule function with its default error message and the
+// argument it expects.
+type builtin struct {
+	fn      Func
+	message string
+	param   paramKind
+}
+
+// builtins are the rules registered on every new Validator.
+var builtins = map[string]builtin{
+	"required":         {required, "is required", paramNone},
+	"min":              {compareSize(func(a, b float64) bool { return a >= b }), "must be at least %s", paramNumber},
+	"max":              {compareSize(func(a, b float64) bool { return a <= b }), "must be at most %s", paramNumber},
+	"len":              {compareSize(func(a, b float64) bool { return a == b }), "must have length %s", paramNumber},
+	"gt":               {compareSize(func(a, b float64) bool { return a > b }), "must be greater than %s", paramNumber},
+	"gte":              {compareSize(func(a, b float64) bool { return a >= b }), "must be greater than or equal to %s", paramNumber},
+	"lt":               {compareSize(func(a, b float64) bool { return a < b }), "must be less than %s", paramNumber},
+	"lte":              {compareSize(func(a, b float64) bool { return a <= b }), "must be less than or equal to %s", paramNumber},
+	"oneof":            {oneOf, "must be one of [%s]", paramList},
+	"email":            {matchString(isEmail), "must be a valid email address", paramNone},
+	"url":              {matchString(isURL), "must be a valid URL", paramNone},
+	"uuid":             {matchString(IsUUID), "must be a valid UUID", paramNone},
+	"alpha":            {matchString(isAlpha), "must contain only letters", paramNone},
+	"alphanum":         {matchString(isAlphanumeric), "must contain only letters and digits", paramNone},
+	"numeric":          {matchString(isNumeric), "must be numeric", paramNone},
+	"eqfield":          {equalField(true), "must be equal to %s", paramField},
+	"nefield":          {equalField(false), "must not be equal to %s", paramField},
+	"gtfield":          {compareField(func(c int) bool { return c > 0 }), "must be greater than %s", paramField},
+	"ltfield":          {compareField(func(c int) bool { return c < 0 }), "must be less than %s", paramField},
+	"required_with":    {requiredWith, "is required when %s is present", paramField},
+	"required_without": {requiredWithout, "is required when %s is absent", paramField},
+}
+
+// checkParam reports why param is not a valid argument of the given kind
+// for a field of struct type t, or "" if it is.
+func checkParam(kind paramKind, param string, t reflect.Type) string {
+	switch kind {
+	case paramNone:
+		if param != "" {
+			return "takes no argument"
+		}
+	case paramNumber:
+		if _, err := strconv.ParseFloat(param, 64); err != nil {
+			return "needs a number"
+		}
+	case paramList:
+		if strings.TrimSpace(param) == "" {
+			return "needs at least one option"
+		}
+	case paramField:
+		if _, ok := t.FieldByName(param); ok {
+			return ""
+		}
+		for i := 0; i < t.NumField(); i++ {
+			if FieldName(t.Field(i)) == param {
+				return ""
+			}
+		}
+		return "names no field of the struct"
+	}
+	return ""
+}
+
+// uuidPattern matches the canonical 8-4-4-4-12 hexadecimal UUID form.
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// IsUUID reports whether s is a UUID in canonical form.
+func IsUUID(s string) bool {
+	return uuidPattern.MatchString(s)
+}
+
+// required fails on zero values, nil pointers and empty strings, slices and maps.
+func required(f Field) bool {
+	return !isEmpty(f.Value)
+}
+
+// isEmpty reports whether v holds a zero or empty value.
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return v.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	case reflect.Invalid:
+		return true
+	}
+	return v.IsZero()
+}
+
+// size returns the value used by size rules: the length of strings,
+// slices and maps, or the numeric value itself.
+func size(v reflect.Value) (float64, bool) {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return 0, false
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), true
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return float64(v.Len()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
+
+// compareSize builds a rule comparing the field's size against the rule argument.
+func compareSize(cmp func(a, b float64) bool) Func {
+	return func(f Field) bool {
+		limit, err := strconv.ParseFloat(f.Param, 64)
+		if err != nil {
+			return false
+		}
+		n, ok := size(f.Value)
+		if !ok {
+			// Nil pointers are left to the required rule.
+			return f.Value.Kind() == reflect.Ptr
+		}
+		return cmp(n, limit)
+	}
+}
+
+// oneOf checks that the field's value is one of the space-separated options.
+func oneOf(f Field) bool {
+	v := reflect.Indirect(f.Value)
+	if !v.IsValid() {
+		return true
+	}
+	var s string
+	switch v.Kind() {
+	case reflect.String:
+		s = v.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		s = strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		s = strconv.FormatUint(v.Uint(), 10)
+	default:
+		return false
+	}
+	for _, option := range strings.Fields(f.Param) {
+		if s == option {
+			return true
+		}
+	}
+	return false
+}
+
+// matchString builds a rule that applies a predicate to string fields.
+// Empty strings pass so that optional fields only need omitempty or required.
+func matchString(pred func(string) bool) Func {
+	return func(f Field) bool {
+		v := reflect.Indirect(f.Value)
+		if !v.IsValid() {
+			return true
+		}
+		if v.Kind() != reflect.String {
+			return false
+		}
+		if v.Len() == 0 {
+			return true
+		}
+		return pred(v.String())
+	}
+}
+
+func isEmail(s string) bool {
+	addr, err := mail.ParseAddress(s)
+	return err == nil && addr.Address == s && strings.Contains(s, "@")
+}
+
+func isURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && u.Scheme != "" && u.Host != ""
+}
+
+func isAlpha(s string) bool {
+	for _, r := range s {
+		if !unicode.IsLetter(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func isAlphanumeric(s string) bool {
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func isNumeric(s string) bool {
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
+
+// sibling returns the field named by the rule argument on the enclosing struct.
+// The argument may use either the Go field name or the json name.
+func sibling(f Field) (reflect.Value, bool) {
+	if !f.Parent.IsValid() || f.Parent.Kind() != reflect.Struct {
+		return reflect.Value{}, false
+	}
+	if v := f.Parent.FieldByName(f.Param); v.IsValid() {
+		return v, true
+	}
+	t := f.Parent.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if FieldName(t.Field(i)) == f.Param {
+			return f.Parent.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// compareField builds a cross-field rule from the result of comparing
+// the field with its sibling (-1, 0 or 1).
+func compareField(ok func(c int) bool) Func {
+	return func(f Field) bool {
+		other, found := sibling(f)
+		if !found {
+			return false
+		}
+		c, comparable := compare(reflect.Indirect(f.Value), reflect.Indirect(other))
+		return comparable && ok(c)
+	}
+}
+
+// equalField builds a rule that checks whether the field equals its sibling.
+// Values that cannot be ordered are compared deeply.
+func equalField(want bool) Func {
+	return func(f Field) bool {
+		other, found := sibling(f)
+		if !found {
+			return false
+		}
+		a, b := reflect.Indirect(f.Value), reflect.Indirect(other)
+		if c, ok := compare(a, b); ok {
+			return (c == 0) == want
+		}
+		if !a.IsValid() || !b.IsValid() || !a.CanInterface() || !b.CanInterface() {
+			return false
+		}
+		return reflect.DeepEqual(a.Interface(), b.Interface()) == want
+	}
+}
+
+// compare orders two values of the same kind. Strings, numbers and
+// time.Time are supported; other types report false.
+func compare(a, b reflect.Value) (int, bool) {
+	if !a.IsValid() || !b.IsValid() || !a.CanInterface() || !b.CanInterface() {
+		return 0, false
+	}
+	if ta, ok := a.Interface().(time.Time); ok {
+		if tb, ok := b.Interface().(time.Time); ok {
+			return ta.Compare(tb), true
+		}
+		return 0, false
+	}
+	if a.Kind() == reflect.String && b.Kind() == reflect.String {
+		return strings.Compare(a.String(), b.String()), true
+	}
+	na, okA := size(a)
+	nb, okB := size(b)
+	if okA && okB && a.Kind() != reflect.Slice && a.Kind() != reflect.Map {
+		switch {
+		case na < nb:
+			return -1, true
+		case na > nb:
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// requiredWith requires the field when the named sibling is non-empty.
+func requiredWith(f Field) bool {
+	other, found := sibling(f)
+	if !found || isEmpty(other) {
+		return true
+	}
+	return !isEmpty(f.Value)
+}
+
+// requiredWithout requires the field when the named sibling is empty.
+func requiredWithout(f Field) bool {
+	other, found := sibling(f)
+	if found && !isEmpty(other) {
+		return true
+	}
+	return !isEmpty(f.Value)
+}
@@ -0,0 +1,338 @@
+package validation
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// FieldError describes a single failed validation rule.
+type FieldError struct {
+	Field   string `json:"field"`           // Path of the field, e.g. "items[0].name".
+	Code    string `json:"code"`            // Name of the failed rule, e.g. "required".
+	Param   string `json:"param,omitempty"` // Rule argument, e.g. "1" for min=1.
+	Message string `json:"message"`         // Human-readable description.
+}
+
+// Errors is the list of field errors returned by Validate.
+type Errors []FieldError
+
+// Error implements the error interface.
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Field + ": " + fe.Message
+	}
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+// Field is passed to rule functions and describes the value being validated.
+type Field struct {
+	Name   string        // Name of the field as reported in errors.
+	Value  reflect.Value // Value of the field.
+	Param  string        // Rule argument, empty if none was given.
+	Parent reflect.Value // Enclosing struct, for cross-field rules.
+}
+
+// Func reports whether a field satisfies a rule.
+type Func func(f Field) bool
+
+// StructRule validates relationships between the fields of a struct.
+// It receives a pointer to the struct and returns the errors it found.
+type StructRule func(v interface{}) Errors
+
+// rule is a parsed entry of a validate tag.
+type rule struct {
+	name  string
+	param string
+}
+
+// fieldInfo caches the parsed tags of a struct field.
+type fieldInfo struct {
+	index     int
+	name      string
+	omitEmpty bool
+	rules     []rule
+}
+
+// TagError reports a malformed validate tag: an unknown rule or an invalid
+// rule argument. It is a programming error, not a problem with the input.
+type TagError struct {
+	Type   reflect.Type // Struct type declaring the field.
+	Field  string       // Go name of the field.
+	Rule   string       // Rule as written in the tag, e.g. "min=abc".
+	Reason string
+}
+
+// Error implements the error interface.
+func (e *TagError) Error() string {
+	return fmt.Sprintf("validation: rule %q on %s.%s %s", e.Rule, e.Type, e.Field, e.Reason)
+}
+
+// Validator evaluates `validate` struct tags.
+type Validator struct {
+	mu          sync.RWMutex
+	rules       map[string]Func
+	messages    map[string]string
+	params      map[string]paramKind
+	structRules map[reflect.Type][]StructRule
+	cache       sync.Map // reflect.Type -> []fieldInfo
+}
+
+// New returns a Validator with the built-in rules registered.
+func New() *Validator {
+	v := &Validator{
+		rules:       make(map[string]Func),
+		messages:    make(map[string]string),
+		params:      make(map[string]paramKind),
+		structRules: make(map[reflect.Type][]StructRule),
+	}
+	for name, b := range builtins {
+		v.rules[name] = b.fn
+		v.messages[name] = b.message
+		v.params[name] = b.param
+	}
+	return v
+}
+
+// RegisterRule adds a custom rule usable in validate tags.
+// The message may contain %s, which is replaced with the rule argument.
+func (v *Validator) RegisterRule(name string, fn Func, message string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.rules[name] = fn
+	v.messages[name] = message
+	v.params[name] = paramAny
+}
+
+// RegisterStructRule adds a cross-field rule for the type of sample.
+// Struct rules run after the field rules of that type.
+func (v *Validator) RegisterStructRule(sample interface{}, fn StructRule) {
+	t := reflect.TypeOf(sample)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.structRules[t] = append(v.structRules[t], fn)
+}
+
+// Validate checks s against its validate tags and registered struct rules.
+// Slices and maps of structs are validated element by element.
+// It returns nil, an Errors value listing every failing field, or a
+// *TagError if a validate tag is malformed.
+func (v *Validator) Validate(s interface{}) error {
+	var errs Errors
+	if err := v.validateValue(reflect.ValueOf(s), "", &errs); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// validateValue walks nested structs, slices and maps, validating every struct found.
+func (v *Validator) validateValue(val reflect.Value, path string, errs *Errors) error {
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return nil
+		}
+		val = val.Elem()
+	}
+	switch val.Kind() {
+	case reflect.Struct:
+		return v.validateStruct(val, path, errs)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < val.Len(); i++ {
+			if err := v.validateValue(val.Index(i), fmt.Sprintf("%s[%d]", path, i), errs); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		iter := val.MapRange()
+		for iter.Next() {
+			if err := v.validateValue(iter.Value(), fmt.Sprintf("%s[%v]", path, iter.Key()), errs); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateStruct applies field rules and struct rules to a struct value.
+func (v *Validator) validateStruct(val reflect.Value, path string, errs *Errors) error {
+	infos, err := v.parse(val.Type())
+	if err != nil {
+		return err
+	}
+	for _, fi := range infos {
+		fv := val.Field(fi.index)
+		name := joinPath(path, fi.name)
+		if !(fi.omitEmpty && isEmpty(fv)) {
+			for _, r := range fi.rules {
+				fn, message, _ := v.lookup(r.name)
+				if !fn(Field{Name: fi.name, Value: fv, Param: r.param, Parent: val}) {
+					*errs = append(*errs, FieldError{
+						Field:   name,
+						Code:    r.name,
+						Param:   r.param,
+						Message: formatMessage(message, r.param),
+					})
+				}
+			}
+		}
+		if err := v.validateValue(fv, name, errs); err != nil {
+			return err
+		}
+	}
+	v.mu.RLock()
+	rules := v.structRules[val.Type()]
+	v.mu.RUnlock()
+	if len(rules) == 0 {
+		return nil
+	}
+	ptr := val
+	if val.CanAddr() {
+		ptr = val.Addr()
+	}
+	for _, rule := range rules {
+		for _, fe := range rule(ptr.Interface()) {
+			fe.Field = joinPath(path, fe.Field)
+			*errs = append(*errs, fe)
+		}
+	}
+	return nil
+}
+
+// lookup returns the function and message registered for a rule.
+func (v *Validator) lookup(name string) (Func, string, bool) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	fn, ok := v.rules[name]
+	return fn, v.messages[name], ok
+}
+
+// parse reads, checks and caches the validate tags of a struct type.
+// Malformed tags are not cached, so rules registered later are picked up.
+func (v *Validator) parse(t reflect.Type) ([]fieldInfo, error) {
+	if cached, ok := v.cache.Load(t); ok {
+		return cached.([]fieldInfo), nil
+	}
+	infos, err := v.parseTags(t)
+	if err != nil {
+		return nil, err
+	}
+	v.cache.Store(t, infos)
+	return infos, nil
+}
+
+// parseTags reads the validate tags of a struct type.
+func (v *Validator) parseTags(t reflect.Type) ([]fieldInfo, error) {
+	var infos []fieldInfo
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		tag := sf.Tag.Get("validate")
+		if tag == "-" {
+			continue
+		}
+		fi := fieldInfo{index: i, name: FieldName(sf)}
+		for _, part := range strings.Split(tag, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			if part == "omitempty" {
+				fi.omitEmpty = true
+				continue
+			}
+			name, param, _ := strings.Cut(part, "=")
+			v.mu.RLock()
+			_, known := v.rules[name]
+			kind := v.params[name]
+			v.mu.RUnlock()
+			if !known {
+				return nil, &TagError{Type: t, Field: sf.Name, Rule: part, Reason: "is unknown"}
+			}
+			if reason := checkParam(kind, param, t); reason != "" {
+				return nil, &TagError{Type: t, Field: sf.Name, Rule: part, Reason: reason}
+			}
+			fi.rules = append(fi.rules, rule{name: name, param: param})
+		}
+		infos = append(infos, fi)
+	}
+	return infos, nil
+}
+
+// Check parses the validate tags of the type of sample and of every struct
+// reachable from it, reporting unknown rules and invalid rule arguments
+// as a *TagError. Call it when a type is
+// registered so that tag mistakes surface at startup instead of per request.
+func (v *Validator) Check(sample interface{}) error {
+	return v.check(reflect.TypeOf(sample), make(map[reflect.Type]bool))
+}
+
+// check walks a type, parsing the tags of each struct found once.
+func (v *Validator) check(t reflect.Type, seen map[reflect.Type]bool) error {
+	for t != nil && (t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map) {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct || seen[t] {
+		return nil
+	}
+	seen[t] = true
+	infos, err := v.parse(t)
+	if err != nil {
+		return err
+	}
+	for _, fi := range infos {
+		if err := v.check(t.Field(fi.index).Type, seen); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FieldName returns the name a struct field is reported under,
+// preferring its json tag so error paths match the request payload.
+func FieldName(sf reflect.StructField) string {
+	if tag := sf.Tag.Get("json"); tag != "" && tag != "-" {
+		if name, _, _ := strings.Cut(tag, ","); name != "" {
+			return name
+		}
+	}
+	return sf.Name
+}
+
+// joinPath appends a field name to a parent path.
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// formatMessage substitutes the rule argument into a message template.
+func formatMessage(msg, param string) string {
+	if msg == "" {
+		return "is invalid"
+	}
+	return strings.ReplaceAll(msg, "%s", param)
+}
+
+// defaultValidator backs the package-level helpers.
+var defaultValidator = New()
+
+// Default returns the package-level Validator.
+func Default() *Validator {
+	return defaultValidator
+}
+
+// Validate checks s using the package-level Validator.
+func Validate(s interface{}) error {
+	return defaultValidator.Validate(s)
+}
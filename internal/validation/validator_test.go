@@ -0,0 +1,123 @@
+package validation
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type point struct{ X, Y int }
+
+type fieldPair struct {
+	A     int      `json:"a"`
+	B     int      `json:"b" validate:"gtfield=A"`
+	P     point    `json:"p"`
+	Q     point    `json:"q" validate:"gtfield=P"`
+	S     []string `json:"s"`
+	T     []string `json:"t" validate:"nefield=S"`
+	U     point    `json:"u" validate:"eqfield=P"`
+	Name  string   `json:"name" validate:"required,min=2"`
+	Email string   `json:"email" validate:"omitempty,email"`
+}
+
+func TestValidateRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		value fieldPair
+		codes []string
+	}{
+		{"zero structs are not ordered", fieldPair{A: 1, B: 2, S: []string{"a"}, T: []string{"b"}, Name: "ok"}, []string{"gtfield"}},
+		{"unordered structs never satisfy gtfield", fieldPair{A: 1, B: 2, P: point{1, 2}, Q: point{3, 4}, S: []string{"a"}, Name: "ok"}, []string{"gtfield", "eqfield"}},
+		{"equal slices fail nefield", fieldPair{A: 1, B: 2, S: []string{"a"}, T: []string{"a"}, Name: "ok"}, []string{"gtfield", "nefield"}},
+		{"field rules", fieldPair{A: 2, B: 1, S: []string{"a"}, Name: "x", Email: "nope"}, []string{"gtfield", "gtfield", "min", "email"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := New().Validate(&tt.value)
+			var errs Errors
+			if err != nil && !errors.As(err, &errs) {
+				t.Fatalf("unexpected error type %T", err)
+			}
+			var codes []string
+			for _, fe := range errs {
+				codes = append(codes, fe.Code)
+			}
+			if strings.Join(codes, ",") != strings.Join(tt.codes, ",") {
+				t.Fatalf("codes = %v, want %v (%v)", codes, tt.codes, err)
+			}
+		})
+	}
+}
+
+func TestCompareUnordered(t *testing.T) {
+	v := New()
+	type pair struct {
+		A map[string]int
+		B map[string]int `validate:"gtfield=A"`
+	}
+	if err := v.Validate(&pair{A: map[string]int{"a": 1}, B: map[string]int{"b": 2}}); err == nil {
+		t.Fatal("gtfield passed for unordered values")
+	}
+}
+
+func TestMalformedTags(t *testing.T) {
+	type unknown struct {
+		Name string `validate:"required,nosuchrule"`
+	}
+	type badNumber struct {
+		Name string `validate:"min=abc"`
+	}
+	type emptyList struct {
+		Role string `validate:"oneof="`
+	}
+	type missingField struct {
+		Confirm string `validate:"eqfield=Password"`
+	}
+	type extraParam struct {
+		Email string `validate:"email=yes"`
+	}
+	type nested struct {
+		Items []badNumber
+	}
+	tests := []struct {
+		name   string
+		value  interface{}
+		reason string
+	}{
+		{"unknown rule", &unknown{Name: "x"}, "nosuchrule"},
+		{"non-numeric argument", &badNumber{Name: "x"}, "needs a number"},
+		{"empty oneof", &emptyList{Role: "x"}, "needs at least one option"},
+		{"unknown sibling", &missingField{Confirm: "x"}, "names no field"},
+		{"unexpected argument", &extraParam{Email: "a@b.c"}, "takes no argument"},
+		{"nested", &nested{Items: []badNumber{{Name: "x"}}}, "needs a number"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := New()
+			if err := v.Check(tt.value); err == nil || !strings.Contains(err.Error(), tt.reason) {
+				t.Fatalf("Check = %v, want %q", err, tt.reason)
+			}
+			err := v.Validate(tt.value)
+			var tagErr *TagError
+			if !errors.As(err, &tagErr) || !strings.Contains(err.Error(), tt.reason) {
+				t.Fatalf("Validate = %v, want a TagError mentioning %q", err, tt.reason)
+			}
+		})
+	}
+}
+
+func TestCustomRule(t *testing.T) {
+	type item struct {
+		Code string `validate:"upper"`
+	}
+	v := New()
+	v.RegisterRule("upper", func(f Field) bool { return strings.ToUpper(f.Value.String()) == f.Value.String() }, "must be upper case")
+	if err := v.Check(item{}); err != nil {
+		t.Fatal(err)
+	}
+	err := v.Validate(&item{Code: "abc"})
+	var errs Errors
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Message != "must be upper case" {
+		t.Fatalf("Validate = %v", err)
+	}
+}
@@ -0,0 +1,256 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/SailfinIO/sail/internal/server"
+	"github.com/SailfinIO/sail/pkg/sail"
+)
+
+// PolicyRegistryName is the container name Authorize resolves the
+// PolicyRegistry under when none is passed.
+const PolicyRegistryName = "auth.policies"
+
+// Route metadata keys set by RequireRoles, RequirePermissions and RequirePolicy.
+const (
+	rolesKey       = "auth.roles"
+	permissionsKey = "auth.permissions"
+	policiesKey    = "auth.policies"
+)
+
+// RoleHolder is implemented by principals that carry roles.
+type RoleHolder interface {
+	Roles() []string
+}
+
+// PermissionHolder is implemented by principals that carry permissions
+// directly, e.g. from a token's scope.
+type PermissionHolder interface {
+	Permissions() []string
+}
+
+// PolicyFunc decides whether a principal may act on a resource. In the
+// Authorize guard the resource is the *http.Request; services pass the
+// entity being accessed to PolicyRegistry.Check.
+type PolicyFunc func(ctx context.Context, p sail.Principal, resource interface{}) bool
+
+// RequireRoles is a route option that admits principals with any of roles.
+func RequireRoles(roles ...string) sail.RouteOption {
+	return sail.SetMetadata(rolesKey, roles)
+}
+
+// RequirePermissions is a route option that admits principals holding all
+// of permissions, directly or through their roles.
+func RequirePermissions(permissions ...string) sail.RouteOption {
+	return sail.SetMetadata(permissionsKey, permissions)
+}
+
+// RequirePolicy is a route option that admits requests for which all of
+// the named policies pass.
+func RequirePolicy(names ...string) sail.RouteOption {
+	return sail.SetMetadata(policiesKey, names)
+}
+
+// PolicyRegistry holds role grants and named policies. Register it in the
+// container under PolicyRegistryName so guards and services share it.
+type PolicyRegistry struct {
+	mu       sync.RWMutex
+	grants   map[string][]string
+	policies map[string]PolicyFunc
+}
+
+// NewPolicyRegistry creates an empty registry.
+func NewPolicyRegistry() *PolicyRegistry {
+	return &PolicyRegistry{
+		grants:   make(map[string][]string),
+		policies: make(map[string]PolicyFunc),
+	}
+}
+
+// PolicyRegistryFrom resolves the registry from a container.
+func PolicyRegistryFrom(c *sail.Container) (*PolicyRegistry, bool) {
+	p, ok := c.Resolve(PolicyRegistryName)
+	if !ok {
+		return nil, false
+	}
+	reg, ok := p.(*PolicyRegistry)
+	return reg, ok
+}
+
+// Grant gives a role permissions. Permissions may end in a wildcard, e.g.
+// "posts:*", and "*" grants everything.
+func (reg *PolicyRegistry) Grant(role string, permissions ...string) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	reg.grants[role] = append(reg.grants[role], permissions...)
+}
+
+// Define registers a named policy, replacing any policy with the same name.
+func (reg *PolicyRegistry) Define(name string, fn PolicyFunc) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	reg.policies[name] = fn
+}
+
+// HasRole reports whether p has any of roles.
+func HasRole(p sail.Principal, roles ...string) bool {
+	holder, ok := p.(RoleHolder)
+	if !ok {
+		return false
+	}
+	for _, have := range holder.Roles() {
+		for _, want := range roles {
+			if have == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// HasPermission reports whether p holds permission, directly or through
+// a role granted it.
+func (reg *PolicyRegistry) HasPermission(p sail.Principal, permission string) bool {
+	if holder, ok := p.(PermissionHolder); ok {
+		for _, have := range holder.Permissions() {
+			if permissionMatches(have, permission) {
+				return true
+			}
+		}
+	}
+	holder, ok := p.(RoleHolder)
+	if !ok {
+		return false
+	}
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	for _, role := range holder.Roles() {
+		for _, have := range reg.grants[role] {
+			if permissionMatches(have, permission) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Can reports whether the named policy passes for p and resource.
+// Unknown policies never pass.
+func (reg *PolicyRegistry) Can(ctx context.Context, p sail.Principal, policy string, resource interface{}) bool {
+	reg.mu.RLock()
+	fn, ok := reg.policies[policy]
+	reg.mu.RUnlock()
+	return ok && fn(ctx, p, resource)
+}
+
+// Check evaluates the named policy for the principal on ctx, for use in
+// services. It returns a 401 HTTPError without a principal and a 403
+// HTTPError when the policy denies access.
+func (reg *PolicyRegistry) Check(ctx context.Context, policy string, resource interface{}) error {
+	p, ok := server.PrincipalFromContext(ctx)
+	if !ok {
+		return server.ErrUnauthenticated()
+	}
+	if !reg.Can(ctx, p, policy, resource) {
+		return forbidden()
+	}
+	return nil
+}
+
+// CheckPermission verifies that the principal on ctx holds all of
+// permissions. It returns the same errors as Check.
+func (reg *PolicyRegistry) CheckPermission(ctx context.Context, permissions ...string) error {
+	p, ok := server.PrincipalFromContext(ctx)
+	if !ok {
+		return server.ErrUnauthenticated()
+	}
+	for _, perm := range permissions {
+		if !reg.HasPermission(p, perm) {
+			return forbidden()
+		}
+	}
+	return nil
+}
+
+// Authorize returns a guard that enforces the route's RequireRoles,
+// RequirePermissions and RequirePolicy metadata. If reg is nil, the
+// registry is resolved from the request's container. Routes without
+// authorization metadata are admitted.
+//
+// The guard needs the matched route, so it must run after routing (with
+// Use, on a group or on a route). Requests without a route, e.g. when it is
+// installed with UseGlobal, are refused with 500 Internal Server Error.
+func Authorize(reg *PolicyRegistry) sail.Guard {
+	return sail.GuardFunc(func(r *http.Request) error {
+		route, ok := server.RouteFromContext(r.Context())
+		if !ok {
+			server.LoggerFromContext(r.Context()).Error(errNoRoute.Error())
+			return errNoRoute
+		}
+		roles, _ := route.Metadata[rolesKey].([]string)
+		perms, _ := route.Metadata[permissionsKey].([]string)
+		policies, _ := route.Metadata[policiesKey].([]string)
+		if len(roles) == 0 && len(perms) == 0 && len(policies) == 0 {
+			return nil
+		}
+		p, ok := server.PrincipalFromContext(r.Context())
+		if !ok {
+			return server.ErrUnauthenticated()
+		}
+		if len(roles) > 0 && !HasRole(p, roles...) {
+			return forbidden()
+		}
+		if len(perms) == 0 && len(policies) == 0 {
+			return nil
+		}
+		registry := reg
+		if registry == nil {
+			if scope, ok := server.ScopeFromContext(r.Context()); ok {
+				registry, _ = PolicyRegistryFrom(scope)
+			}
+			if registry == nil {
+				server.LoggerFromContext(r.Context()).Error("auth: no PolicyRegistry registered as " + PolicyRegistryName)
+				return forbidden()
+			}
+		}
+		for _, perm := range perms {
+			if !registry.HasPermission(p, perm) {
+				return forbidden()
+			}
+		}
+		for _, policy := range policies {
+			if !registry.Can(r.Context(), p, policy, r) {
+				return forbidden()
+			}
+		}
+		return nil
+	})
+}
+
+// permissionMatches reports whether a granted permission covers a
+// required one, honoring trailing wildcards.
+func permissionMatches(granted, required string) bool {
+	if granted == "*" || granted == required {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(granted, "*"); ok {
+		return strings.HasPrefix(required, prefix)
+	}
+	return false
+}
+
+// errNoRoute is returned by Authorize when no route has been matched.
+var errNoRoute = errors.New("auth: Authorize requires a matched route; install it after routing, not with UseGlobal")
+
+// forbidden is the error for denied requests.
+func forbidden() error {
+	return &server.HTTPError{
+		Status:  http.StatusForbidden,
+		Code:    "forbidden",
+		Message: "You do not have permission to perform this action",
+	}
+}
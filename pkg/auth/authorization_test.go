@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/SailfinIO/sail/internal/server"
+	"github.com/SailfinIO/sail/pkg/sail"
+)
+
+func TestAuthorize(t *testing.T) {
+	reg := NewPolicyRegistry()
+	reg.Grant("editor", "posts:*")
+	reg.Define("owner", func(_ context.Context, p sail.Principal, _ interface{}) bool {
+		return p.Subject() == "alice"
+	})
+	alice := &Identity{ID: "alice", RoleNames: []string{"editor"}}
+	bob := &Identity{ID: "bob", RoleNames: []string{"viewer"}}
+	tests := []struct {
+		name      string
+		global    bool
+		principal sail.Principal
+		opts      []sail.RouteOption
+		status    int
+	}{
+		{"no metadata", false, nil, nil, http.StatusOK},
+		{"anonymous", false, nil, []sail.RouteOption{RequireRoles("editor")}, http.StatusUnauthorized},
+		{"role held", false, alice, []sail.RouteOption{RequireRoles("editor")}, http.StatusOK},
+		{"role missing", false, bob, []sail.RouteOption{RequireRoles("editor")}, http.StatusForbidden},
+		{"wildcard permission", false, alice, []sail.RouteOption{RequirePermissions("posts:write")}, http.StatusOK},
+		{"permission missing", false, bob, []sail.RouteOption{RequirePermissions("posts:write")}, http.StatusForbidden},
+		{"policy", false, alice, []sail.RouteOption{RequirePolicy("owner")}, http.StatusOK},
+		{"policy denied", false, bob, []sail.RouteOption{RequirePolicy("owner")}, http.StatusForbidden},
+		{"global guard fails closed", true, alice, []sail.RouteOption{RequireRoles("editor")}, http.StatusInternalServerError},
+		{"global guard fails closed without metadata", true, alice, nil, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := server.NewRouter()
+			if tt.principal != nil {
+				router.UseGlobal(func(next http.Handler) http.Handler {
+					return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						next.ServeHTTP(w, r.WithContext(server.WithPrincipal(r.Context(), tt.principal)))
+					})
+				})
+			}
+			guard := server.UseGuards(Authorize(reg))
+			if tt.global {
+				router.UseGlobal(guard)
+			} else {
+				router.Use(guard)
+			}
+			router.Handle("GET /posts", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), tt.opts...)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/posts", nil))
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/SailfinIO/sail/pkg/sail"
+)
+
+// BasicValidator returns the principal for a username and password, or
+// ErrInvalidCredentials if they are wrong.
+type BasicValidator func(ctx context.Context, username, password string) (sail.Principal, error)
+
+// BasicOptions configures the Basic strategy.
+type BasicOptions struct {
+	// Realm is sent in the WWW-Authenticate challenge. Defaults to "Restricted".
+	Realm string
+	// Validate checks credentials. Required; see StaticUsers.
+	Validate BasicValidator
+}
+
+// BasicStrategy authenticates requests with HTTP Basic credentials.
+type BasicStrategy struct {
+	opts BasicOptions
+}
+
+// NewBasicStrategy creates a Basic strategy. It panics if opts.Validate is nil.
+func NewBasicStrategy(opts BasicOptions) *BasicStrategy {
+	if opts.Validate == nil {
+		panic("auth: BasicOptions.Validate is required")
+	}
+	if opts.Realm == "" {
+		opts.Realm = "Restricted"
+	}
+	return &BasicStrategy{opts: opts}
+}
+
+// Name implements AuthStrategy.
+func (s *BasicStrategy) Name() string {
+	return "basic"
+}
+
+// Challenge implements Challenger.
+func (s *BasicStrategy) Challenge() string {
+	return "Basic realm=" + strconv.Quote(s.opts.Realm) + `, charset="UTF-8"`
+}
+
+// Authenticate implements AuthStrategy.
+func (s *BasicStrategy) Authenticate(r *http.Request) (sail.Principal, error) {
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return nil, ErrNoCredentials
+	}
+	return s.opts.Validate(r.Context(), username, password)
+}
+
+// StaticUsers validates credentials against a fixed map of username to
+// stored password, checked with hasher. Unknown usernames are checked
+// against another user's stored password so they take as long as wrong
+// passwords. The principal is an *Identity for the username.
+func StaticUsers(hasher Hasher, users map[string]string) BasicValidator {
+	var dummy string
+	for _, stored := range users {
+		dummy = stored
+		break
+	}
+	return func(_ context.Context, username, password string) (sail.Principal, error) {
+		stored, known := users[username]
+		if !known {
+			stored = dummy
+		}
+		ok, err := hasher.Verify(stored, password)
+		if err != nil {
+			return nil, err
+		}
+		if !known || !ok {
+			return nil, ErrInvalidCredentials
+		}
+		return &Identity{ID: username, Strategy: "basic"}, nil
+	}
+}
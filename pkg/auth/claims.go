@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"strings"
+	"time"
+)
+
+// Claims holds the payload of a verified JWT. It implements sail.Principal
+// so guards and handlers can read it from the request context.
+type Claims map[string]interface{}
+
+// Subject returns the "sub" claim.
+func (c Claims) Subject() string {
+	return c.String("sub")
+}
+
+// Issuer returns the "iss" claim.
+func (c Claims) Issuer() string {
+	return c.String("iss")
+}
+
+// Audience returns the "aud" claim, which may be a string or a list.
+func (c Claims) Audience() []string {
+	return c.Strings("aud")
+}
+
+// ExpiresAt returns the "exp" claim.
+func (c Claims) ExpiresAt() (time.Time, bool) {
+	return c.Time("exp")
+}
+
+// NotBefore returns the "nbf" claim.
+func (c Claims) NotBefore() (time.Time, bool) {
+	return c.Time("nbf")
+}
+
+// IssuedAt returns the "iat" claim.
+func (c Claims) IssuedAt() (time.Time, bool) {
+	return c.Time("iat")
+}
+
+// Roles returns the "roles" claim. It implements RoleHolder.
+func (c Claims) Roles() []string {
+	return c.Strings("roles")
+}
+
+// Permissions returns the "permissions" claim together with the scopes of
+// the "scope" claim. It implements PermissionHolder.
+func (c Claims) Permissions() []string {
+	return append(c.Strings("permissions"), c.Scopes()...)
+}
+
+// Scopes returns the "scope" claim. A single string is split on spaces, as
+// defined by RFC 8693; a list is returned as is.
+func (c Claims) Scopes() []string {
+	if s, ok := c["scope"].(string); ok {
+		return strings.Fields(s)
+	}
+	return c.Strings("scope")
+}
+
+// String returns a string claim, or an empty string.
+func (c Claims) String(name string) string {
+	s, _ := c[name].(string)
+	return s
+}
+
+// Strings returns a claim holding a string or a list of strings. A single
+// string is returned as a list of one.
+func (c Claims) Strings(name string) []string {
+	switch v := c[name].(type) {
+	case string:
+		if v == "" {
+			return nil
+		}
+		return []string{v}
+	case []string:
+		return v
+	case []interface{}:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// Time returns a NumericDate claim (seconds since the Unix epoch).
+func (c Claims) Time(name string) (time.Time, bool) {
+	switch v := c[name].(type) {
+	case float64:
+		return time.Unix(0, int64(v*float64(time.Second))), true
+	case int64:
+		return time.Unix(v, 0), true
+	case int:
+		return time.Unix(int64(v), 0), true
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+)
+
+// Hasher checks a secret against its stored form. Implement it to use
+// bcrypt, argon2 or another password hash, e.g.
+//
+//	auth.HasherFunc(func(hash, secret string) (bool, error) {
+//		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(secret))
+//		return err == nil, nil
+//	})
+//
+// Implementations must compare in constant time.
+type Hasher interface {
+	Verify(hash, secret string) (bool, error)
+}
+
+// HasherFunc adapts a function to the Hasher interface.
+type HasherFunc func(hash, secret string) (bool, error)
+
+// Verify implements Hasher.
+func (f HasherFunc) Verify(hash, secret string) (bool, error) {
+	return f(hash, secret)
+}
+
+// PlainHasher compares secrets stored in plain text.
+var PlainHasher Hasher = HasherFunc(func(stored, secret string) (bool, error) {
+	return subtle.ConstantTimeCompare([]byte(stored), []byte(secret)) == 1, nil
+})
+
+// SHA256Hasher compares secrets stored as hex-encoded SHA-256 digests.
+// It suits high-entropy secrets such as API keys; use a password hash
+// such as bcrypt or argon2 for user passwords.
+var SHA256Hasher Hasher = HasherFunc(func(hash, secret string) (bool, error) {
+	sum := sha256.Sum256([]byte(secret))
+	return subtle.ConstantTimeCompare([]byte(hash), []byte(hex.EncodeToString(sum[:]))) == 1, nil
+})
+
+// HashSHA256 returns the hex-encoded SHA-256 digest of secret, for
+// storing API keys verified with SHA256Hasher.
+func HashSHA256(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
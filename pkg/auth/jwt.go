@@ -0,0 +1,340 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/SailfinIO/sail/internal/server"
+	"github.com/SailfinIO/sail/pkg/sail"
+)
+
+// Errors returned by Verifier.Verify.
+var (
+	ErrMalformedToken       = errors.New("auth: malformed token")
+	ErrUnsupportedAlgorithm = errors.New("auth: unsupported signing algorithm")
+	ErrInvalidSignature     = errors.New("auth: invalid token signature")
+	ErrTokenExpired         = errors.New("auth: token is expired")
+	ErrTokenNotYetValid     = errors.New("auth: token is not yet valid")
+	ErrMissingExpiry        = errors.New("auth: token has no expiry")
+	ErrInvalidIssuer        = errors.New("auth: invalid token issuer")
+	ErrInvalidAudience      = errors.New("auth: invalid token audience")
+)
+
+// tokenErrors are the Verify errors that reject a token.
+var tokenErrors = []error{
+	ErrMalformedToken, ErrUnsupportedAlgorithm, ErrInvalidSignature, ErrTokenExpired,
+	ErrTokenNotYetValid, ErrMissingExpiry, ErrInvalidIssuer, ErrInvalidAudience,
+}
+
+// defaultAlgorithms are the signing algorithms accepted by default.
+var defaultAlgorithms = []string{"HS256", "RS256", "ES256", "EdDSA"}
+
+// JWTOptions configures JWT verification.
+type JWTOptions struct {
+	// Keys supplies the verification keys. Required.
+	Keys KeySource
+	// Algorithms lists the accepted signing algorithms. Defaults to
+	// HS256, RS256, ES256 and EdDSA.
+	Algorithms []string
+	// Issuer, if set, must equal the token's "iss" claim.
+	Issuer string
+	// Audience, if set, must be one of the token's "aud" values.
+	Audience string
+	// ClockSkew is the leeway applied to "exp" and "nbf".
+	ClockSkew time.Duration
+	// AllowMissingExpiry accepts tokens without an "exp" claim.
+	AllowMissingExpiry bool
+	// CookieName, if set, is read for the token when the request has no
+	// Authorization header.
+	CookieName string
+	// Optional lets requests without a token through anonymously so that
+	// guards decide which routes need authentication. Invalid tokens are
+	// still rejected.
+	Optional bool
+}
+
+// OptionsFromConfig builds JWTOptions from configuration keys:
+//
+//	JWT_SECRET           HS256 secret
+//	JWT_PUBLIC_KEY_FILE  PEM file with public keys
+//	JWT_JWKS_FILE        local JWKS file
+//	JWT_ALGORITHMS       comma-separated accepted algorithms
+//	JWT_ISSUER           expected issuer
+//	JWT_AUDIENCE         expected audience
+//	JWT_CLOCK_SKEW       leeway in seconds
+//	JWT_COOKIE           cookie holding the token
+func OptionsFromConfig(cfg *sail.ConfigService) JWTOptions {
+	var sources []KeySource
+	if cfg.Get("JWT_SECRET") != "" {
+		sources = append(sources, ConfigSecret(cfg, "JWT_SECRET"))
+	}
+	if path := cfg.Get("JWT_PUBLIC_KEY_FILE"); path != "" {
+		sources = append(sources, PEMFile(path))
+	}
+	if path := cfg.Get("JWT_JWKS_FILE"); path != "" {
+		sources = append(sources, JWKSFile(path))
+	}
+	opts := JWTOptions{
+		Issuer:     cfg.Get("JWT_ISSUER"),
+		Audience:   cfg.Get("JWT_AUDIENCE"),
+		ClockSkew:  time.Duration(cfg.GetInt("JWT_CLOCK_SKEW")) * time.Second,
+		CookieName: cfg.Get("JWT_COOKIE"),
+	}
+	if len(sources) > 0 {
+		opts.Keys = CombineKeySources(sources...)
+	}
+	if algs := cfg.Get("JWT_ALGORITHMS"); algs != "" {
+		for _, alg := range strings.Split(algs, ",") {
+			opts.Algorithms = append(opts.Algorithms, strings.TrimSpace(alg))
+		}
+	}
+	return opts
+}
+
+// Verifier validates JWTs.
+type Verifier struct {
+	opts       JWTOptions
+	algorithms map[string]bool
+	now        func() time.Time
+}
+
+// NewVerifier creates a Verifier. It panics if opts.Keys is nil.
+func NewVerifier(opts JWTOptions) *Verifier {
+	if opts.Keys == nil {
+		panic("auth: JWTOptions.Keys is required")
+	}
+	if len(opts.Algorithms) == 0 {
+		opts.Algorithms = defaultAlgorithms
+	}
+	v := &Verifier{opts: opts, algorithms: make(map[string]bool), now: time.Now}
+	for _, alg := range opts.Algorithms {
+		v.algorithms[alg] = true
+	}
+	return v
+}
+
+// jwtHeader is the decoded JOSE header.
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	Typ string `json:"typ"`
+}
+
+// Verify checks a compact JWT's signature and registered claims and
+// returns its claims.
+func (v *Verifier) Verify(ctx context.Context, token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformedToken
+	}
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, ErrMalformedToken
+	}
+	if !v.algorithms[header.Alg] {
+		return nil, ErrUnsupportedAlgorithm
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformedToken
+	}
+	keys, err := v.opts.Keys.Keys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	signed := []byte(parts[0] + "." + parts[1])
+	verified := false
+	for _, k := range keys {
+		if k.ID != "" && header.Kid != "" && k.ID != header.Kid {
+			continue
+		}
+		if k.Algorithm != "" && k.Algorithm != header.Alg {
+			continue
+		}
+		if verifySignature(header.Alg, k.Key, signed, sig) {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, ErrInvalidSignature
+	}
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil || claims == nil {
+		return nil, ErrMalformedToken
+	}
+	if err := v.validate(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// validate checks the time, issuer and audience claims.
+func (v *Verifier) validate(c Claims) error {
+	now := v.now()
+	skew := v.opts.ClockSkew
+	if exp, ok := c.ExpiresAt(); ok {
+		if !now.Before(exp.Add(skew)) {
+			return ErrTokenExpired
+		}
+	} else if !v.opts.AllowMissingExpiry {
+		return ErrMissingExpiry
+	}
+	if nbf, ok := c.NotBefore(); ok && now.Add(skew).Before(nbf) {
+		return ErrTokenNotYetValid
+	}
+	if v.opts.Issuer != "" && c.Issuer() != v.opts.Issuer {
+		return ErrInvalidIssuer
+	}
+	if v.opts.Audience != "" {
+		found := false
+		for _, aud := range c.Audience() {
+			if aud == v.opts.Audience {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return ErrInvalidAudience
+		}
+	}
+	return nil
+}
+
+// decodeSegment decodes a base64url JSON segment.
+func decodeSegment(seg string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	// Unmarshal rejects trailing data after the JSON value.
+	return json.Unmarshal(data, v)
+}
+
+// verifySignature checks sig over signed with key. The key type must
+// match the algorithm, which prevents algorithm confusion attacks.
+func verifySignature(alg string, key interface{}, signed, sig []byte) bool {
+	sum := sha256.Sum256(signed)
+	switch alg {
+	case "HS256":
+		secret, ok := key.([]byte)
+		if !ok || len(secret) == 0 {
+			return false
+		}
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(signed)
+		return hmac.Equal(mac.Sum(nil), sig)
+	case "RS256":
+		pub, ok := key.(*rsa.PublicKey)
+		return ok && pub.Size() >= 256 && rsa.VerifyPKCS1v15(pub, crypto.SHA256, sum[:], sig) == nil
+	case "ES256":
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok || pub.Curve != elliptic.P256() || len(sig) != 64 {
+			return false
+		}
+		r := new(big.Int).SetBytes(sig[:32])
+		s := new(big.Int).SetBytes(sig[32:])
+		return ecdsa.Verify(pub, sum[:], r, s)
+	case "EdDSA":
+		pub, ok := key.(ed25519.PublicKey)
+		return ok && len(pub) == ed25519.PublicKeySize && ed25519.Verify(pub, signed, sig)
+	}
+	return false
+}
+
+// JWT returns a middleware that verifies the bearer token of each request
+// and stores its Claims as the request's principal. Requests without a
+// valid token are rejected with 401 Unauthorized unless opts.Optional is
+// set and no token was sent.
+func JWT(opts JWTOptions) sail.Middleware {
+	return NewVerifier(opts).Middleware()
+}
+
+// Middleware returns the verifier as a middleware; see JWT.
+func (v *Verifier) Middleware() sail.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := v.token(r)
+			if token == "" {
+				if v.opts.Optional {
+					next.ServeHTTP(w, r)
+					return
+				}
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				server.WriteError(w, server.ErrUnauthenticated())
+				return
+			}
+			claims, err := v.Verify(r.Context(), token)
+			if err != nil && !rejected(err) {
+				server.LoggerFromContext(r.Context()).Error("jwt key source: " + err.Error())
+				server.WriteError(w, err)
+				return
+			}
+			if err != nil {
+				server.LoggerFromContext(r.Context()).Debug("jwt rejected: " + err.Error())
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				server.WriteError(w, &server.HTTPError{
+					Status:  http.StatusUnauthorized,
+					Code:    "invalid_token",
+					Message: "Invalid or expired token",
+				})
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(server.WithPrincipal(r.Context(), claims)))
+		})
+	}
+}
+
+// Name implements AuthStrategy.
+func (v *Verifier) Name() string {
+	return "jwt"
+}
+
+// Challenge implements Challenger.
+func (v *Verifier) Challenge() string {
+	return "Bearer"
+}
+
+// Authenticate implements AuthStrategy, so a Verifier can be chained with
+// other strategies in Authenticate.
+func (v *Verifier) Authenticate(r *http.Request) (sail.Principal, error) {
+	token := v.token(r)
+	if token == "" {
+		return nil, ErrNoCredentials
+	}
+	claims, err := v.Verify(r.Context(), token)
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// token extracts the token from the Authorization header or the cookie.
+func (v *Verifier) token(r *http.Request) string {
+	if h := r.Header.Get("Authorization"); h != "" {
+		scheme, token, ok := strings.Cut(h, " ")
+		if ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+		return ""
+	}
+	if v.opts.CookieName != "" {
+		if c, err := r.Cookie(v.opts.CookieName); err == nil {
+			return c.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testSecret = []byte("0123456789abcdef0123456789abcdef")
+
+// signHS256 builds a compact token from raw header and claims JSON.
+func signHS256(header, claims string) string {
+	enc := base64.RawURLEncoding
+	signed := enc.EncodeToString([]byte(header)) + "." + enc.EncodeToString([]byte(claims))
+	mac := hmac.New(sha256.New, testSecret)
+	mac.Write([]byte(signed))
+	return signed + "." + enc.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifierVerify(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	exp := fmt.Sprintf(`"exp":%d`, now.Add(time.Hour).Unix())
+	const hs = `{"alg":"HS256","typ":"JWT"}`
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"valid", signHS256(hs, `{"sub":"alice","aud":"api",`+exp+`}`), nil},
+		{"expired", signHS256(hs, fmt.Sprintf(`{"aud":"api","exp":%d}`, now.Add(-time.Hour).Unix())), ErrTokenExpired},
+		{"missing expiry", signHS256(hs, `{"aud":"api"}`), ErrMissingExpiry},
+		{"wrong audience", signHS256(hs, `{"aud":"other",`+exp+`}`), ErrInvalidAudience},
+		{"space-separated audience", signHS256(hs, `{"aud":"other api",`+exp+`}`), ErrInvalidAudience},
+		{"audience list", signHS256(hs, `{"sub":"alice","aud":["other","api"],`+exp+`}`), nil},
+		{"none algorithm", signHS256(`{"alg":"none"}`, `{`+exp+`}`), ErrUnsupportedAlgorithm},
+		{"tampered signature", signHS256(hs, `{`+exp+`}`) + "A", ErrInvalidSignature},
+		{"trailing bytes after claims", signHS256(hs, `{`+exp+`}{"sub":"root"}`), ErrMalformedToken},
+		{"trailing bytes after header", signHS256(hs+`x`, `{`+exp+`}`), ErrMalformedToken},
+		{"two segments", "a.b", ErrMalformedToken},
+	}
+	v := NewVerifier(JWTOptions{Keys: StaticKeys(Key{Key: testSecret}), Audience: "api"})
+	v.now = func() time.Time { return now }
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := v.Verify(context.Background(), tt.token)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Verify = %v, want %v", err, tt.want)
+			}
+			if err == nil && claims.Subject() != "alice" {
+				t.Fatalf("subject = %q", claims.Subject())
+			}
+		})
+	}
+}
+
+func TestJWTMiddleware(t *testing.T) {
+	token := signHS256(`{"alg":"HS256"}`, `{"sub":"alice"}`)
+	tests := []struct {
+		name     string
+		auth     string
+		optional bool
+		status   int
+		message  string
+	}{
+		{"valid", "Bearer " + token, false, http.StatusOK, ""},
+		{"missing", "", false, http.StatusUnauthorized, "Authentication required"},
+		{"missing but optional", "", true, http.StatusOK, ""},
+		{"invalid", "Bearer x.y.z", true, http.StatusUnauthorized, "Invalid or expired token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mw := JWT(JWTOptions{Keys: StaticKeys(Key{Key: testSecret}), AllowMissingExpiry: true, Optional: tt.optional})
+			h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.auth != "" {
+				r.Header.Set("Authorization", tt.auth)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != tt.status || !strings.Contains(w.Body.String(), tt.message) {
+				t.Fatalf("got %d %s, want %d %q", w.Code, w.Body.String(), tt.status, tt.message)
+			}
+		})
+	}
+}
+
+func TestClaimsLists(t *testing.T) {
+	c := Claims{"aud": "a b", "roles": "admin editor", "scope": "read write", "permissions": []interface{}{"delete"}}
+	if got := c.Audience(); !reflect.DeepEqual(got, []string{"a b"}) {
+		t.Fatalf("Audience = %q", got)
+	}
+	if got := c.Roles(); !reflect.DeepEqual(got, []string{"admin editor"}) {
+		t.Fatalf("Roles = %q", got)
+	}
+	if got := c.Permissions(); !reflect.DeepEqual(got, []string{"delete", "read", "write"}) {
+		t.Fatalf("Permissions = %q", got)
+	}
+}
@@ -0,0 +1,270 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/SailfinIO/sail/pkg/sail"
+)
+
+// Key is a verification key. Key holds a []byte secret for HS256, an
+// *rsa.PublicKey for RS256, an *ecdsa.PublicKey on P-256 for ES256 or an
+// ed25519.PublicKey for EdDSA.
+type Key struct {
+	ID        string // Key ID matched against the token's "kid"; empty matches any token.
+	Algorithm string // Restricts the key to one algorithm, if set.
+	Key       interface{}
+}
+
+// KeySource supplies the keys tokens are verified against. It is called
+// for every token, so sources that rotate keys return the current set.
+type KeySource interface {
+	Keys(ctx context.Context) ([]Key, error)
+}
+
+// KeySourceFunc adapts a function to the KeySource interface.
+type KeySourceFunc func(ctx context.Context) ([]Key, error)
+
+// Keys implements KeySource.
+func (f KeySourceFunc) Keys(ctx context.Context) ([]Key, error) {
+	return f(ctx)
+}
+
+// StaticKeys returns a KeySource with a fixed set of keys.
+func StaticKeys(keys ...Key) KeySource {
+	return KeySourceFunc(func(context.Context) ([]Key, error) {
+		return keys, nil
+	})
+}
+
+// ConfigSecret returns a KeySource with an HS256 secret read from the
+// named configuration key on every call, so it follows config changes.
+func ConfigSecret(cfg *sail.ConfigService, name string) KeySource {
+	return KeySourceFunc(func(context.Context) ([]Key, error) {
+		secret := cfg.Get(name)
+		if secret == "" {
+			return nil, fmt.Errorf("auth: config key %s is not set", name)
+		}
+		return []Key{{Algorithm: "HS256", Key: []byte(secret)}}, nil
+	})
+}
+
+// CombineKeySources merges the keys of several sources, e.g. a config
+// secret for internal tokens and a JWKS file for an identity provider.
+func CombineKeySources(sources ...KeySource) KeySource {
+	return KeySourceFunc(func(ctx context.Context) ([]Key, error) {
+		var all []Key
+		for _, s := range sources {
+			keys, err := s.Keys(ctx)
+			if err != nil {
+				return nil, err
+			}
+			all = append(all, keys...)
+		}
+		return all, nil
+	})
+}
+
+// PEMFile returns a KeySource with the public keys in a PEM file
+// (PUBLIC KEY, RSA PUBLIC KEY or CERTIFICATE blocks). The file is
+// reloaded when it changes, so keys can be rotated in place.
+func PEMFile(path string) KeySource {
+	return &fileKeys{path: path, parse: parsePEM}
+}
+
+// JWKSFile returns a KeySource with the keys of a local JSON Web Key Set
+// file. The file is reloaded when it changes, so keys can be rotated by
+// publishing the new key alongside the old one.
+func JWKSFile(path string) KeySource {
+	return &fileKeys{path: path, parse: parseJWKS}
+}
+
+// fileCheckInterval is how often key files are checked for changes.
+const fileCheckInterval = time.Second
+
+// fileKeys loads keys from a file and reloads them when its modification
+// time or size changes.
+type fileKeys struct {
+	path    string
+	parse   func([]byte) ([]Key, error)
+	mu      sync.Mutex
+	keys    []Key
+	modTime time.Time
+	size    int64
+	checked time.Time
+}
+
+// Keys implements KeySource. If a reload fails, the previously loaded
+// keys stay in use.
+func (f *fileKeys) Keys(context.Context) ([]Key, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := time.Now()
+	if f.keys != nil && now.Sub(f.checked) < fileCheckInterval {
+		return f.keys, nil
+	}
+	f.checked = now
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return f.loaded(err)
+	}
+	if f.keys != nil && info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+		return f.keys, nil
+	}
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return f.loaded(err)
+	}
+	keys, err := f.parse(data)
+	if err != nil {
+		return f.loaded(fmt.Errorf("auth: %s: %w", f.path, err))
+	}
+	f.keys, f.modTime, f.size = keys, info.ModTime(), info.Size()
+	return keys, nil
+}
+
+// loaded returns the previously loaded keys, or err if there are none.
+func (f *fileKeys) loaded(err error) ([]Key, error) {
+	if f.keys != nil {
+		return f.keys, nil
+	}
+	return nil, err
+}
+
+// parsePEM decodes the public keys in PEM data.
+func parsePEM(data []byte) ([]Key, error) {
+	var keys []Key
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		var pub interface{}
+		var err error
+		switch block.Type {
+		case "PUBLIC KEY":
+			pub, err = x509.ParsePKIXPublicKey(block.Bytes)
+		case "RSA PUBLIC KEY":
+			pub, err = x509.ParsePKCS1PublicKey(block.Bytes)
+		case "CERTIFICATE":
+			var cert *x509.Certificate
+			if cert, err = x509.ParseCertificate(block.Bytes); err == nil {
+				pub = cert.PublicKey
+			}
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, Key{Key: pub})
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no public keys found")
+	}
+	return keys, nil
+}
+
+// jwk is a single JSON Web Key.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	K   string `json:"k"`
+}
+
+// parseJWKS decodes a JSON Web Key Set. Keys that are not signature keys
+// or use unsupported types are skipped.
+func parseJWKS(data []byte) ([]Key, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+	var keys []Key
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		pub, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", k.Kid, err)
+		}
+		if pub != nil {
+			keys = append(keys, Key{ID: k.Kid, Algorithm: k.Alg, Key: pub})
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no signature keys found")
+	}
+	return keys, nil
+}
+
+// publicKey converts a JWK to a verification key, or nil if its type is unsupported.
+func (k jwk) publicKey() (interface{}, error) {
+	dec := base64.RawURLEncoding.DecodeString
+	switch k.Kty {
+	case "oct":
+		return dec(k.K)
+	case "RSA":
+		n, err := dec(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := dec(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, nil
+		}
+		x, err := dec(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := dec(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != 32 || len(y) != 32 {
+			return nil, errors.New("invalid P-256 coordinates")
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, nil
+		}
+		x, err := dec(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key size")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,47 @@
+package auth
+
+import (
+	"errors"
+
+	"github.com/SailfinIO/sail/pkg/sail"
+)
+
+// VerifierName is the container name the JWTModule registers its Verifier under.
+const VerifierName = "auth.jwt"
+
+// JWTModule adds JWT authentication to an App. Every request carrying a
+// bearer token is verified and its claims become the request principal;
+// requests without a token continue anonymously, so routes opt into
+// authentication with sail.UseGuards(sail.Authenticated()).
+type JWTModule struct {
+	// Options configures verification. When Options.Keys is nil, the
+	// options are read from the app's configuration with OptionsFromConfig.
+	Options  JWTOptions
+	Verifier *Verifier
+	app      *sail.App
+}
+
+// SetApp sets the app instance for the module.
+func (m *JWTModule) SetApp(app *sail.App) {
+	m.app = app
+}
+
+// OnModuleInit creates the Verifier, registers it in the container and
+// installs the authentication middleware.
+func (m *JWTModule) OnModuleInit() error {
+	if m.app == nil {
+		return errors.New("app instance not set")
+	}
+	opts := m.Options
+	if opts.Keys == nil {
+		opts = OptionsFromConfig(m.app.Config())
+		if opts.Keys == nil {
+			return errors.New("auth: no JWT keys configured (JWT_SECRET, JWT_PUBLIC_KEY_FILE or JWT_JWKS_FILE)")
+		}
+	}
+	opts.Optional = true
+	m.Verifier = NewVerifier(opts)
+	m.app.Container().Register(VerifierName, m.Verifier)
+	m.app.UseGlobal(m.Verifier.Middleware())
+	return nil
+}
@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"crypto/x509"
+	"net/http"
+	"path"
+
+	"github.com/SailfinIO/sail/internal/server"
+	"github.com/SailfinIO/sail/pkg/sail"
+)
+
+// ClientCertificate is the principal of a request authenticated with a
+// verified TLS client certificate.
+type ClientCertificate struct {
+	Certificate    *x509.Certificate
+	SubjectDN      string   // Distinguished name, e.g. "CN=billing,O=Acme".
+	CommonName     string   // Subject common name.
+	DNSNames       []string // DNS name SANs.
+	EmailAddresses []string // Email address SANs.
+	IPAddresses    []string // IP address SANs, in their string form.
+	URIs           []string // URI SANs.
+	SPIFFEID       string   // The spiffe:// URI SAN, if any.
+}
+
+// Subject implements sail.Principal. It returns the SPIFFE ID when the
+// certificate has one and the subject DN otherwise.
+func (c *ClientCertificate) Subject() string {
+	if c.SPIFFEID != "" {
+		return c.SPIFFEID
+	}
+	return c.SubjectDN
+}
+
+// ClientCertificateFromRequest returns the identity of the request's
+// verified client certificate. Certificates that were presented but not
+// verified against the server's client CAs are ignored.
+func ClientCertificateFromRequest(r *http.Request) (*ClientCertificate, bool) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil, false
+	}
+	cert := r.TLS.VerifiedChains[0][0]
+	c := &ClientCertificate{
+		Certificate:    cert,
+		SubjectDN:      cert.Subject.String(),
+		CommonName:     cert.Subject.CommonName,
+		DNSNames:       cert.DNSNames,
+		EmailAddresses: cert.EmailAddresses,
+	}
+	for _, ip := range cert.IPAddresses {
+		c.IPAddresses = append(c.IPAddresses, ip.String())
+	}
+	for _, uri := range cert.URIs {
+		c.URIs = append(c.URIs, uri.String())
+		if uri.Scheme == "spiffe" && c.SPIFFEID == "" {
+			c.SPIFFEID = uri.String()
+		}
+	}
+	return c, true
+}
+
+// MTLSStrategy authenticates requests by their verified TLS client
+// certificate; the principal is a *ClientCertificate. The server must be
+// configured with a client CA (TLS_CLIENT_CA_FILE).
+type MTLSStrategy struct{}
+
+// NewMTLSStrategy creates a client certificate strategy.
+func NewMTLSStrategy() *MTLSStrategy {
+	return &MTLSStrategy{}
+}
+
+// Name implements AuthStrategy.
+func (s *MTLSStrategy) Name() string {
+	return "mtls"
+}
+
+// Authenticate implements AuthStrategy.
+func (s *MTLSStrategy) Authenticate(r *http.Request) (sail.Principal, error) {
+	c, ok := ClientCertificateFromRequest(r)
+	if !ok {
+		return nil, ErrNoCredentials
+	}
+	return c, nil
+}
+
+// ClientCertGuardOptions lists the client certificates a route admits.
+// Patterns use path.Match syntax. Each list is only matched against SANs
+// of its own type, so that e.g. a DNS pattern cannot be satisfied by a URI
+// or email address SAN. A certificate is admitted if any pattern matches.
+type ClientCertGuardOptions struct {
+	DNSNames       []string // DNS name SANs, e.g. "*.billing.internal".
+	EmailAddresses []string // Email address SANs, e.g. "*@billing.example".
+	IPAddresses    []string // IP address SANs, e.g. "10.0.1.*".
+	URIs           []string // URI SANs, e.g. "spiffe://example.org/ns/prod/*".
+	// Subjects match the subject DN or common name, e.g. "CN=billing,*".
+	Subjects []string
+}
+
+// ClientCertGuard returns a guard that admits requests whose verified
+// client certificate matches opts. Requests without one are rejected
+// with 401, other certificates with 403.
+func ClientCertGuard(opts ClientCertGuardOptions) sail.Guard {
+	return sail.GuardFunc(func(r *http.Request) error {
+		c, ok := ClientCertificateFromRequest(r)
+		if !ok {
+			return &server.HTTPError{
+				Status:  http.StatusUnauthorized,
+				Code:    "client_certificate_required",
+				Message: "A verified client certificate is required",
+			}
+		}
+		if matchAny(opts.DNSNames, c.DNSNames) || matchAny(opts.EmailAddresses, c.EmailAddresses) ||
+			matchAny(opts.IPAddresses, c.IPAddresses) || matchAny(opts.URIs, c.URIs) {
+			return nil
+		}
+		for _, pattern := range opts.Subjects {
+			if matchPattern(pattern, c.SubjectDN) || matchPattern(pattern, c.CommonName) {
+				return nil
+			}
+		}
+		return forbidden()
+	})
+}
+
+// matchAny reports whether any value matches any of patterns.
+func matchAny(patterns, values []string) bool {
+	for _, pattern := range patterns {
+		for _, v := range values {
+			if matchPattern(pattern, v) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchPattern reports whether s matches a path.Match pattern.
+// Malformed patterns never match.
+func matchPattern(pattern, s string) bool {
+	ok, err := path.Match(pattern, s)
+	return err == nil && ok
+}
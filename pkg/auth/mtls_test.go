@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/SailfinIO/sail/internal/server"
+)
+
+func TestClientCertGuard(t *testing.T) {
+	spiffe, _ := url.Parse("spiffe://example.org/ns/prod/billing")
+	cert := &x509.Certificate{
+		Subject:        pkix.Name{CommonName: "billing", Organization: []string{"Acme"}},
+		DNSNames:       []string{"api.billing.internal"},
+		EmailAddresses: []string{"ops@billing.internal"},
+		IPAddresses:    []net.IP{net.ParseIP("10.0.1.7")},
+		URIs:           []*url.URL{spiffe},
+	}
+	tests := []struct {
+		name     string
+		opts     ClientCertGuardOptions
+		verified bool // The certificate chain was verified.
+		status   int
+		subject  string
+	}{
+		{"dns SAN", ClientCertGuardOptions{DNSNames: []string{"*.billing.internal"}}, true, http.StatusOK, spiffe.String()},
+		{"email SAN", ClientCertGuardOptions{EmailAddresses: []string{"*@billing.internal"}}, true, http.StatusOK, spiffe.String()},
+		{"ip SAN", ClientCertGuardOptions{IPAddresses: []string{"10.0.1.*"}}, true, http.StatusOK, spiffe.String()},
+		{"spiffe SAN", ClientCertGuardOptions{URIs: []string{"spiffe://example.org/ns/prod/*"}}, true, http.StatusOK, spiffe.String()},
+		{"dns pattern does not match other SAN types", ClientCertGuardOptions{DNSNames: []string{"spiffe://*", "*@billing.internal", "10.0.1.7"}}, true, http.StatusForbidden, ""},
+		{"uri pattern does not match a DNS SAN", ClientCertGuardOptions{URIs: []string{"api.billing.internal"}}, true, http.StatusForbidden, ""},
+		{"common name", ClientCertGuardOptions{Subjects: []string{"billing"}}, true, http.StatusOK, spiffe.String()},
+		{"subject DN", ClientCertGuardOptions{Subjects: []string{"CN=billing,*"}}, true, http.StatusOK, spiffe.String()},
+		{"no match", ClientCertGuardOptions{DNSNames: []string{"*.payments.internal"}, Subjects: []string{"payments"}}, true, http.StatusForbidden, ""},
+		{"malformed pattern", ClientCertGuardOptions{DNSNames: []string{"[*"}}, true, http.StatusForbidden, ""},
+		{"unverified certificate", ClientCertGuardOptions{Subjects: []string{"billing"}}, false, http.StatusUnauthorized, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var subject string
+			strategy := Authenticate(NewMTLSStrategy())
+			guard := server.UseGuards(ClientCertGuard(tt.opts))
+			h := guard(strategy(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				p, _ := server.PrincipalFromContext(r.Context())
+				subject = p.Subject()
+			})))
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+			if tt.verified {
+				r.TLS.VerifiedChains = [][]*x509.Certificate{{cert}}
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != tt.status || subject != tt.subject {
+				t.Fatalf("got %d for %q, want %d for %q", w.Code, subject, tt.status, tt.subject)
+			}
+		})
+	}
+}
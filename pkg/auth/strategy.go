@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/SailfinIO/sail/internal/server"
+	"github.com/SailfinIO/sail/pkg/sail"
+)
+
+// ErrNoCredentials is returned by strategies when the request carries no
+// credentials for them, so the next strategy in a chain is tried.
+var ErrNoCredentials = errors.New("auth: no credentials")
+
+// ErrInvalidCredentials is returned by strategies when the request's
+// credentials are wrong. Other errors are treated as failures of the
+// credential backend and answered with 500 Internal Server Error.
+var ErrInvalidCredentials = errors.New("auth: invalid credentials")
+
+// rejected reports whether err rejects the request's credentials, as
+// opposed to a failure of the store, hasher or key source checking them.
+func rejected(err error) bool {
+	if errors.Is(err, ErrInvalidCredentials) {
+		return true
+	}
+	for _, e := range tokenErrors {
+		if errors.Is(err, e) {
+			return true
+		}
+	}
+	return false
+}
+
+// AuthStrategy authenticates requests by one mechanism, similar to a
+// Passport strategy. Authenticate returns ErrNoCredentials when the request
+// does not use the strategy's mechanism.
+type AuthStrategy interface {
+	Name() string
+	Authenticate(r *http.Request) (sail.Principal, error)
+}
+
+// Challenger is implemented by strategies that advertise themselves in the
+// WWW-Authenticate header of 401 responses.
+type Challenger interface {
+	Challenge() string
+}
+
+// Identity is the principal produced by the API key and Basic strategies.
+type Identity struct {
+	ID              string   // Subject, e.g. a user or client ID.
+	Strategy        string   // Name of the strategy that authenticated the request.
+	RoleNames       []string // Roles, returned by Roles.
+	PermissionNames []string // Permissions held directly, returned by Permissions.
+}
+
+// Subject implements sail.Principal.
+func (i *Identity) Subject() string {
+	return i.ID
+}
+
+// Roles implements RoleHolder.
+func (i *Identity) Roles() []string {
+	return i.RoleNames
+}
+
+// Permissions implements PermissionHolder.
+func (i *Identity) Permissions() []string {
+	return i.PermissionNames
+}
+
+// Authenticate returns a middleware that tries strategies in order and
+// stores the first principal returned on the request context. A strategy
+// returning a nil principal without an error is treated as having
+// rejected the credentials. If none
+// succeeds the request is rejected with 401 Unauthorized; a strategy
+// failing with any other error than ErrNoCredentials or
+// ErrInvalidCredentials ends the chain with a logged 500. Different
+// routes or groups can chain different strategies, e.g.
+// Authenticate(jwtVerifier, apiKeys).
+func Authenticate(strategies ...AuthStrategy) sail.Middleware {
+	return authenticate(false, strategies)
+}
+
+// AuthenticateOptional is like Authenticate but lets requests without any
+// credentials through anonymously. Invalid credentials are still rejected.
+func AuthenticateOptional(strategies ...AuthStrategy) sail.Middleware {
+	return authenticate(true, strategies)
+}
+
+// isNil reports whether p is nil or holds a nil pointer.
+func isNil(p sail.Principal) bool {
+	if p == nil {
+		return true
+	}
+	v := reflect.ValueOf(p)
+	return v.Kind() == reflect.Pointer && v.IsNil()
+}
+
+// authenticate builds the Authenticate middleware.
+func authenticate(optional bool, strategies []AuthStrategy) sail.Middleware {
+	var challenges []string
+	for _, s := range strategies {
+		if c, ok := s.(Challenger); ok {
+			challenges = append(challenges, c.Challenge())
+		}
+	}
+	challenge := strings.Join(challenges, ", ")
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var failed error
+			for _, s := range strategies {
+				p, err := s.Authenticate(r)
+				if err == nil && isNil(p) {
+					// A strategy must not authenticate a request without
+					// saying who made it.
+					err = ErrInvalidCredentials
+				}
+				if err == nil {
+					next.ServeHTTP(w, r.WithContext(server.WithPrincipal(r.Context(), p)))
+					return
+				}
+				if errors.Is(err, ErrNoCredentials) {
+					continue
+				}
+				if !rejected(err) {
+					server.LoggerFromContext(r.Context()).Error(s.Name() + " authentication error: " + err.Error())
+					server.WriteError(w, err)
+					return
+				}
+				server.LoggerFromContext(r.Context()).Debug(s.Name() + " authentication failed: " + err.Error())
+				if failed == nil {
+					failed = err
+				}
+			}
+			if failed == nil && optional {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if challenge != "" {
+				w.Header().Set("WWW-Authenticate", challenge)
+			}
+			httpErr := server.ErrUnauthenticated()
+			if failed != nil {
+				httpErr.Code = "invalid_credentials"
+				httpErr.Message = "Invalid credentials"
+			}
+			server.WriteError(w, httpErr)
+		})
+	}
+}
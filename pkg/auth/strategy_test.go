@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/SailfinIO/sail/internal/server"
+	"github.com/SailfinIO/sail/pkg/sail"
+)
+
+func TestAuthenticate(t *testing.T) {
+	backendDown := HasherFunc(func(string, string) (bool, error) {
+		return false, errors.New("store unavailable")
+	})
+	keys := map[string]string{"client": HashSHA256("secret")}
+	calls := 0
+	counting := HasherFunc(func(hash, secret string) (bool, error) {
+		calls++
+		return SHA256Hasher.Verify(hash, secret)
+	})
+	many := map[string]string{"client": HashSHA256("secret"), "other": HashSHA256("x"), "third": HashSHA256("y")}
+	nilPrincipal := NewAPIKeyStrategy(APIKeyOptions{Validate: func(context.Context, string) (sail.Principal, error) {
+		return (*Identity)(nil), nil
+	}})
+	users := map[string]string{"alice": "pw"}
+	tests := []struct {
+		name       string
+		strategies []AuthStrategy
+		setup      func(r *http.Request)
+		status     int
+		subject    string
+	}{
+		{"api key", []AuthStrategy{NewAPIKeyStrategy(APIKeyOptions{Validate: StaticAPIKeys(SHA256Hasher, keys)})},
+			func(r *http.Request) { r.Header.Set(DefaultAPIKeyHeader, "client.secret") }, http.StatusOK, "client"},
+		{"api key verifies one entry", []AuthStrategy{NewAPIKeyStrategy(APIKeyOptions{Validate: StaticAPIKeys(counting, many)})},
+			func(r *http.Request) { r.Header.Set(DefaultAPIKeyHeader, "client.secret") }, http.StatusOK, "client"},
+		{"wrong api key", []AuthStrategy{NewAPIKeyStrategy(APIKeyOptions{Validate: StaticAPIKeys(SHA256Hasher, keys)})},
+			func(r *http.Request) { r.Header.Set(DefaultAPIKeyHeader, "client.nope") }, http.StatusUnauthorized, ""},
+		{"api key for another client", []AuthStrategy{NewAPIKeyStrategy(APIKeyOptions{Validate: StaticAPIKeys(SHA256Hasher, many)})},
+			func(r *http.Request) { r.Header.Set(DefaultAPIKeyHeader, "other.secret") }, http.StatusUnauthorized, ""},
+		{"api key without client ID", []AuthStrategy{NewAPIKeyStrategy(APIKeyOptions{Validate: StaticAPIKeys(SHA256Hasher, keys)})},
+			func(r *http.Request) { r.Header.Set(DefaultAPIKeyHeader, "secret") }, http.StatusUnauthorized, ""},
+		{"api key hasher error", []AuthStrategy{NewAPIKeyStrategy(APIKeyOptions{Validate: StaticAPIKeys(backendDown, keys)})},
+			func(r *http.Request) { r.Header.Set(DefaultAPIKeyHeader, "client.secret") }, http.StatusInternalServerError, ""},
+		{"nil principal", []AuthStrategy{nilPrincipal},
+			func(r *http.Request) { r.Header.Set(DefaultAPIKeyHeader, "client.secret") }, http.StatusUnauthorized, ""},
+		{"basic", []AuthStrategy{NewBasicStrategy(BasicOptions{Validate: StaticUsers(PlainHasher, users)})},
+			func(r *http.Request) { r.SetBasicAuth("alice", "pw") }, http.StatusOK, "alice"},
+		{"basic unknown user", []AuthStrategy{NewBasicStrategy(BasicOptions{Validate: StaticUsers(PlainHasher, users)})},
+			func(r *http.Request) { r.SetBasicAuth("bob", "pw") }, http.StatusUnauthorized, ""},
+		{"basic backend error", []AuthStrategy{NewBasicStrategy(BasicOptions{Validate: func(context.Context, string, string) (sail.Principal, error) {
+			return nil, errors.New("database unavailable")
+		}})}, func(r *http.Request) { r.SetBasicAuth("alice", "pw") }, http.StatusInternalServerError, ""},
+		{"no credentials", []AuthStrategy{NewBasicStrategy(BasicOptions{Validate: StaticUsers(PlainHasher, users)})},
+			func(r *http.Request) {}, http.StatusUnauthorized, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var subject string
+			h := Authenticate(tt.strategies...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				p, _ := server.PrincipalFromContext(r.Context())
+				subject = p.Subject()
+			}))
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			tt.setup(r)
+			w := httptest.NewRecorder()
+			calls = 0
+			h.ServeHTTP(w, r)
+			if w.Code != tt.status || subject != tt.subject {
+				t.Fatalf("got %d for %q, want %d for %q", w.Code, subject, tt.status, tt.subject)
+			}
+			if calls > 1 {
+				t.Fatalf("hasher ran %d times, want at most 1", calls)
+			}
+		})
+	}
+}
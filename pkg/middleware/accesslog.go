@@ -0,0 +1,228 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SailfinIO/sail/internal/logger"
+	"github.com/SailfinIO/sail/internal/server"
+)
+
+// AccessLogFormat selects the layout of access log entries.
+type AccessLogFormat int
+
+const (
+	// AccessLogDev is a short, colored format for local development.
+	AccessLogDev AccessLogFormat = iota
+	// AccessLogCombined is the Apache combined log format.
+	AccessLogCombined
+	// AccessLogJSON writes one JSON object per request.
+	AccessLogJSON
+)
+
+// AccessLogOptions configures the AccessLog middleware.
+type AccessLogOptions struct {
+	// Logger, if set, receives the entries instead of Output. Entries for
+	// 5xx responses are logged as errors and 4xx as warnings.
+	Logger logger.Logger
+	// Output receives one line per entry when Logger is nil. Defaults to
+	// os.Stdout.
+	Output io.Writer
+	// Format selects the entry layout. Defaults to AccessLogDev.
+	Format AccessLogFormat
+	// SkipPaths lists request paths that are not logged, e.g. "/healthz".
+	// A trailing "*" matches any path with the given prefix.
+	SkipPaths []string
+	// SkipStatuses lists response statuses that are not logged.
+	SkipStatuses []int
+	// Skip, if set, suppresses entries for which it returns true.
+	Skip func(r *http.Request, status int) bool
+	// TrustedProxies lists the addresses and CIDR ranges of reverse proxies
+	// whose X-Forwarded-For entries are used to find the remote IP.
+	TrustedProxies []string
+}
+
+// accessEntry holds the fields of one access log entry.
+type accessEntry struct {
+	Time      time.Time `json:"time"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	Route     string    `json:"route,omitempty"`
+	Proto     string    `json:"proto"`
+	Status    int       `json:"status"`
+	Bytes     int64     `json:"bytes"`
+	LatencyMS float64   `json:"latency_ms"`
+	RemoteIP  string    `json:"remote_ip"`
+	RequestID string    `json:"request_id,omitempty"`
+	Referer   string    `json:"referer,omitempty"`
+	UserAgent string    `json:"user_agent,omitempty"`
+	User      string    `json:"user,omitempty"`
+}
+
+// AccessLog returns a middleware that writes an entry for every request once
+// its response has been written, to Logger if set and to Output otherwise.
+// It panics if TrustedProxies is invalid.
+func AccessLog(opts AccessLogOptions) func(http.Handler) http.Handler {
+	out := opts.Output
+	if out == nil {
+		out = os.Stdout
+	}
+	var mu sync.Mutex
+	proxies := mustParseTrustedProxies(opts.TrustedProxies)
+	skipStatus := make(map[int]bool, len(opts.SkipStatuses))
+	for _, s := range opts.SkipStatuses {
+		skipStatus[s] = true
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if skipPath(opts.SkipPaths, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			start := time.Now()
+			rw := newResponseWriter(w)
+			r = r.WithContext(server.TrackPrincipal(r.Context()))
+			next.ServeHTTP(rw, r)
+			if skipStatus[rw.status] || (opts.Skip != nil && opts.Skip(r, rw.status)) {
+				return
+			}
+			entry := accessEntry{
+				Time:      start,
+				Method:    r.Method,
+				Path:      r.URL.RequestURI(),
+				Route:     routePattern(r),
+				Proto:     r.Proto,
+				Status:    rw.status,
+				Bytes:     rw.bytes,
+				LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+				RemoteIP:  proxies.ClientIP(r),
+				RequestID: server.RequestIDFromContext(r.Context()),
+				Referer:   r.Referer(),
+				UserAgent: r.UserAgent(),
+			}
+			if entry.RequestID == "" {
+				entry.RequestID = w.Header().Get(DefaultRequestIDHeader)
+			}
+			if p, ok := server.PrincipalFromContext(r.Context()); ok {
+				entry.User = p.Subject()
+			}
+			msg := formatAccessEntry(opts.Format, entry)
+			if opts.Logger != nil {
+				switch {
+				case rw.status >= 500:
+					opts.Logger.Error(msg)
+				case rw.status >= 400:
+					opts.Logger.Warn(msg)
+				default:
+					opts.Logger.Info(msg)
+				}
+				return
+			}
+			line := msg + "\n"
+			mu.Lock()
+			io.WriteString(out, line)
+			mu.Unlock()
+		})
+	}
+}
+
+// formatAccessEntry renders an entry in the given format.
+func formatAccessEntry(format AccessLogFormat, e accessEntry) string {
+	switch format {
+	case AccessLogCombined:
+		return fmt.Sprintf(`%s - %s [%s] "%s %s %s" %d %s "%s" "%s"`,
+			e.RemoteIP, dash(escapeLogField(e.User)), e.Time.Format("02/Jan/2006:15:04:05 -0700"),
+			escapeLogField(e.Method), escapeLogField(e.Path), escapeLogField(e.Proto), e.Status, bytesField(e.Bytes),
+			dash(escapeLogField(e.Referer)), dash(escapeLogField(e.UserAgent)))
+	case AccessLogJSON:
+		b, _ := json.Marshal(e)
+		return string(b)
+	}
+	msg := fmt.Sprintf("%s %s %s", e.RemoteIP, escapeLogField(e.Method), e.Path)
+	if e.Route != "" {
+		msg += " (" + escapeLogField(e.Route) + ")"
+	}
+	msg += fmt.Sprintf(" %s%d%s %s %s", statusColor(e.Status), e.Status, logger.ColorReset,
+		time.Duration(e.LatencyMS*float64(time.Millisecond)).Round(time.Microsecond), bytesField(e.Bytes))
+	if e.RequestID != "" {
+		msg += " " + e.RequestID
+	}
+	return msg
+}
+
+// statusColor returns the ANSI color used for a status in the dev format.
+func statusColor(status int) string {
+	switch {
+	case status >= 500:
+		return logger.ColorRed
+	case status >= 400:
+		return logger.ColorYellow
+	case status >= 300:
+		return logger.ColorBlue
+	}
+	return logger.ColorGreen
+}
+
+// skipPath reports whether path matches one of the skip patterns.
+func skipPath(patterns []string, path string) bool {
+	for _, p := range patterns {
+		if prefix, ok := strings.CutSuffix(p, "*"); ok {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+		} else if p == path {
+			return true
+		}
+	}
+	return false
+}
+
+// routePattern returns the pattern of the route that served r.
+func routePattern(r *http.Request) string {
+	if route, ok := server.RouteFromContext(r.Context()); ok {
+		return route.Pattern
+	}
+	return r.Pattern
+}
+
+// escapeLogField escapes quotes, backslashes and non-printable bytes the
+// way Apache does, so that client-supplied values cannot break a log line.
+func escapeLogField(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < 0x20 || c >= 0x7f:
+			fmt.Fprintf(&b, "\\x%02x", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// dash returns "-" for empty log fields.
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// bytesField formats a body size, using "-" for empty bodies as Apache does.
+func bytesField(n int64) string {
+	if n == 0 {
+		return "-"
+	}
+	return strconv.FormatInt(n, 10)
+}
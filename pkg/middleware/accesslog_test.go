@@ -0,0 +1,154 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/SailfinIO/sail/internal/logger"
+	"github.com/SailfinIO/sail/internal/server"
+)
+
+type testPrincipal string
+
+func (p testPrincipal) Subject() string { return string(p) }
+
+func TestAccessLogFormats(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The principal is attached after the access log middleware has run.
+		r = r.WithContext(server.WithPrincipal(r.Context(), testPrincipal("bob")))
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("hello"))
+	})
+	tests := []struct {
+		name   string
+		format AccessLogFormat
+		check  func(t *testing.T, line string)
+	}{
+		{"json", AccessLogJSON, func(t *testing.T, line string) {
+			var e map[string]interface{}
+			if err := json.Unmarshal([]byte(line), &e); err != nil {
+				t.Fatalf("not valid JSON: %v: %q", err, line)
+			}
+			if e["status"] != float64(201) || e["user"] != "bob" || e["remote_ip"] != "192.0.2.1" {
+				t.Fatalf("entry = %v", e)
+			}
+		}},
+		{"combined", AccessLogCombined, func(t *testing.T, line string) {
+			if !strings.HasPrefix(line, `192.0.2.1 - bob [`) {
+				t.Fatalf("line = %q", line)
+			}
+			if !strings.Contains(line, `"GET /a%22b HTTP/1.1" 201 5 "-" "agent \"x\"\x0a"`) {
+				t.Fatalf("line not escaped: %q", line)
+			}
+		}},
+		{"dev", AccessLogDev, func(t *testing.T, line string) {
+			if !strings.HasPrefix(line, "192.0.2.1 GET /a%22b (/{name}) ") || strings.Contains(line, "INFO") {
+				t.Fatalf("line = %q", line)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			mux := http.NewServeMux()
+			mux.Handle("/{name}", handler)
+			h := AccessLog(AccessLogOptions{Output: &buf, Format: tt.format})(mux)
+			r := httptest.NewRequest(http.MethodGet, "/a%22b", nil)
+			r.RemoteAddr = "192.0.2.1:1234"
+			r.Header.Set("User-Agent", "agent \"x\"\n")
+			h.ServeHTTP(httptest.NewRecorder(), r)
+			out := buf.String()
+			if strings.Count(out, "\n") != 1 || !strings.HasSuffix(out, "\n") {
+				t.Fatalf("want one line, got %q", out)
+			}
+			tt.check(t, strings.TrimSuffix(out, "\n"))
+		})
+	}
+}
+
+type levelLogger struct {
+	lines *[]string
+}
+
+func (l levelLogger) WithContext(string) logger.Logger { return l }
+func (l levelLogger) Info(msg string)                  { *l.lines = append(*l.lines, "INFO "+msg) }
+func (l levelLogger) Warn(msg string)                  { *l.lines = append(*l.lines, "WARN "+msg) }
+func (l levelLogger) Error(msg string)                 { *l.lines = append(*l.lines, "ERROR "+msg) }
+func (l levelLogger) Debug(msg string)                 { *l.lines = append(*l.lines, "DEBUG "+msg) }
+
+func TestAccessLogLogger(t *testing.T) {
+	var lines []string
+	var buf bytes.Buffer
+	h := AccessLog(AccessLogOptions{Logger: levelLogger{&lines}, Output: &buf, Format: AccessLogJSON})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status, _ := strconv.Atoi(r.URL.Query().Get("status"))
+		w.WriteHeader(status)
+	}))
+	for _, status := range []string{"200", "404", "503"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/?status="+status, nil))
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("Output written with a Logger set: %q", buf.String())
+	}
+	want := []string{"INFO ", "WARN ", "ERROR "}
+	if len(lines) != len(want) {
+		t.Fatalf("lines = %q", lines)
+	}
+	for i, prefix := range want {
+		if !strings.HasPrefix(lines[i], prefix+"{") {
+			t.Fatalf("line %d = %q, want level %q", i, lines[i], prefix)
+		}
+	}
+}
+
+func TestAccessLogSkip(t *testing.T) {
+	var buf bytes.Buffer
+	h := AccessLog(AccessLogOptions{Output: &buf, SkipPaths: []string{"/healthz", "/static/*"}, SkipStatuses: []int{404}})(http.NotFoundHandler())
+	for _, path := range []string{"/healthz", "/static/app.js", "/missing"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("unexpected entries: %q", buf.String())
+	}
+}
+
+func TestTrustedProxiesClientIP(t *testing.T) {
+	proxies, err := ParseTrustedProxies("10.0.0.0/8", "192.0.2.10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name       string
+		proxies    *TrustedProxies
+		remoteAddr string
+		forwarded  []string
+		want       string
+	}{
+		{"no proxies ignores header", nil, "203.0.113.5:1", []string{"1.1.1.1"}, "203.0.113.5"},
+		{"untrusted peer ignores header", proxies, "203.0.113.5:1", []string{"1.1.1.1"}, "203.0.113.5"},
+		{"trusted peer", proxies, "10.0.0.1:1", []string{"198.51.100.7"}, "198.51.100.7"},
+		{"spoofed leftmost entry", proxies, "10.0.0.1:1", []string{"6.6.6.6, 198.51.100.7"}, "198.51.100.7"},
+		{"chain of proxies", proxies, "10.0.0.1:1", []string{"6.6.6.6, 198.51.100.7, 192.0.2.10", "10.1.2.3"}, "198.51.100.7"},
+		{"all trusted", proxies, "10.0.0.1:1", []string{"10.0.0.2"}, "10.0.0.2"},
+		{"no header", proxies, "10.0.0.1:1", nil, "10.0.0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for _, v := range tt.forwarded {
+				r.Header.Add("X-Forwarded-For", v)
+			}
+			if got := tt.proxies.ClientIP(r); got != tt.want {
+				t.Fatalf("ClientIP = %q, want %q", got, tt.want)
+			}
+		})
+	}
+	if _, err := ParseTrustedProxies("not-an-ip"); err == nil {
+		t.Fatal("ParseTrustedProxies accepted an invalid address")
+	}
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/SailfinIO/sail/internal/server"
+)
+
+// BodyLimit returns a middleware that rejects request bodies larger than
+// maxBytes with 413 Payload Too Large. Requests declaring a larger
+// Content-Length are rejected before the handler runs; otherwise reads past
+// the limit fail with *http.MaxBytesError, which sail.Decode reports as a 413.
+// It panics if maxBytes is not positive.
+func BodyLimit(maxBytes int64) func(http.Handler) http.Handler {
+	if maxBytes <= 0 {
+		panic("middleware: BodyLimit requires a positive maxBytes")
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > maxBytes {
+				server.WriteError(w, payloadTooLarge(maxBytes))
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// payloadTooLarge builds the 413 error sent for bodies over limit bytes.
+func payloadTooLarge(limit int64) *server.HTTPError {
+	return &server.HTTPError{
+		Status:  http.StatusRequestEntityTooLarge,
+		Code:    "payload_too_large",
+		Message: "Request body exceeds " + strconv.FormatInt(limit, 10) + " bytes",
+	}
+}
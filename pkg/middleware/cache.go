@@ -0,0 +1,452 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SailfinIO/sail/internal/cache"
+	"github.com/SailfinIO/sail/internal/server"
+)
+
+// Route metadata keys set by CacheTTL and CacheTags.
+const (
+	cacheTTLKey  = "cache.ttl"
+	cacheTagsKey = "cache.tags"
+)
+
+// CacheTTL is a route option that sets how long the route's responses are
+// cached. A TTL of zero disables caching for the route.
+func CacheTTL(ttl time.Duration) server.RouteOption {
+	return server.SetMetadata(cacheTTLKey, ttl)
+}
+
+// CacheTags is a route option that tags the route's cached responses for
+// invalidation with CacheStore.InvalidateTags. Tags may reference path
+// wildcards, e.g. CacheTags("products", "product:{id}").
+func CacheTags(tags ...string) server.RouteOption {
+	return server.SetMetadata(cacheTagsKey, tags)
+}
+
+// CacheOptions configures the Cache middleware.
+type CacheOptions struct {
+	// Store holds cached responses. Share it with services that invalidate
+	// entries. Defaults to a new in-memory LRU store of 1000 entries.
+	Store cache.Store
+	// TTL is how long responses are cached when neither the route nor the
+	// response sets a lifetime. Defaults to one minute.
+	TTL time.Duration
+	// StaleWhileRevalidate is how long an expired response may still be
+	// served while it is refreshed in the background, unless the response
+	// sets its own stale-while-revalidate directive.
+	StaleWhileRevalidate time.Duration
+	// VaryHeaders are request headers whose values are part of the cache
+	// key, e.g. Accept or Accept-Language. Requests with an Authorization
+	// or Cookie header are only served shared responses unless that header
+	// is listed here.
+	VaryHeaders []string
+}
+
+// Cache returns a middleware that caches successful GET and HEAD responses,
+// keyed by method, scheme, host, path, query, VaryHeaders and the request
+// headers named in the response's Vary header. It honors Cache-Control on requests (no-store,
+// no-cache, max-age=0) and responses (no-store, no-cache, private, public,
+// s-maxage, max-age, stale-while-revalidate). The X-Cache response header
+// reports HIT, MISS, STALE or BYPASS.
+//
+// Responses to requests carrying credentials (Authorization or Cookie) and
+// responses that set cookies are only cached when marked public; cookies
+// and other per-request headers are never stored.
+//
+// The lifetime of a response is taken from s-maxage, then the route's
+// CacheTTL, then max-age, then CacheOptions.TTL.
+func Cache(opts CacheOptions) func(http.Handler) http.Handler {
+	if opts.Store == nil {
+		opts.Store = cache.NewMemoryStore(1000)
+	}
+	if opts.TTL <= 0 {
+		opts.TTL = time.Minute
+	}
+	c := &responseCache{opts: opts, revalidating: make(map[string]bool)}
+	for _, h := range opts.VaryHeaders {
+		switch http.CanonicalHeaderKey(h) {
+		case "Authorization":
+			c.varyAuth = true
+		case "Cookie":
+			c.varyCookie = true
+		}
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+			reqCC := parseCacheControl(r.Header.Get("Cache-Control"))
+			if _, noStore := reqCC["no-store"]; noStore {
+				w.Header().Set("X-Cache", "BYPASS")
+				next.ServeHTTP(w, r)
+				return
+			}
+			ctx := r.Context()
+			key := c.key(r)
+			credentialed := c.credentialed(r)
+			_, noCache := reqCC["no-cache"]
+			if !noCache && reqCC["max-age"] != "0" {
+				entry, ok, err := c.lookup(ctx, r, key)
+				if err != nil {
+					server.LoggerFromContext(ctx).Error("cache store: " + err.Error())
+				}
+				if ok && (!credentialed || isPublic(entry.Header)) {
+					now := time.Now()
+					if entry.Fresh(now) {
+						serveEntry(w, r, entry, "HIT", now)
+						return
+					}
+					if now.Before(entry.Expires()) {
+						serveEntry(w, r, entry, "STALE", now)
+						c.revalidate(next, r, key, credentialed)
+						return
+					}
+				}
+			}
+
+			if credentialed {
+				w.Header().Set("X-Cache", "BYPASS")
+			} else {
+				w.Header().Set("X-Cache", "MISS")
+			}
+			gen, ok := c.generation(r)
+			cw := &captureWriter{responseWriter: newResponseWriter(w)}
+			next.ServeHTTP(cw, r)
+			if !ok {
+				return
+			}
+			header := cw.header
+			if header == nil {
+				header = w.Header().Clone()
+			}
+			c.store(r, key, credentialed, gen, cw.status, header, cw.body.Bytes())
+		})
+	}
+}
+
+// responseCache holds the state shared by one Cache middleware.
+type responseCache struct {
+	opts         CacheOptions
+	varyAuth     bool
+	varyCookie   bool
+	mu           sync.Mutex
+	revalidating map[string]bool
+}
+
+// perRequestHeaders are response headers that describe one exchange and are
+// never stored.
+var perRequestHeaders = []string{"Set-Cookie", "X-Request-Id", "X-Cache", "Date", "Age"}
+
+// credentialed reports whether a request carries credentials that are not
+// part of the cache key.
+func (c *responseCache) credentialed(r *http.Request) bool {
+	return (r.Header.Get("Authorization") != "" && !c.varyAuth) ||
+		(r.Header.Get("Cookie") != "" && !c.varyCookie)
+}
+
+// lookup returns the entry for key, following a Vary entry to the variant
+// that matches the request.
+func (c *responseCache) lookup(ctx context.Context, r *http.Request, key string) (*cache.Entry, bool, error) {
+	entry, ok, err := c.opts.Store.Get(ctx, key)
+	if err != nil || !ok || len(entry.Vary) == 0 {
+		return entry, ok, err
+	}
+	return c.opts.Store.Get(ctx, variantKey(key, entry.Vary, r))
+}
+
+// key builds the cache key of a request. It includes the scheme and host so
+// that a request for one virtual host cannot fill the cache for another.
+func (c *responseCache) key(r *http.Request) string {
+	var b strings.Builder
+	b.WriteString(r.Method)
+	b.WriteByte(' ')
+	if r.TLS != nil {
+		b.WriteString("https://")
+	} else {
+		b.WriteString("http://")
+	}
+	b.WriteString(strings.ToLower(r.Host))
+	b.WriteString(r.URL.Path)
+	if q := r.URL.Query(); len(q) > 0 {
+		b.WriteByte('?')
+		b.WriteString(q.Encode())
+	}
+	for _, h := range c.opts.VaryHeaders {
+		b.WriteByte('\n')
+		b.WriteString(http.CanonicalHeaderKey(h))
+		b.WriteByte(':')
+		b.WriteString(strings.Join(r.Header.Values(h), ","))
+	}
+	return b.String()
+}
+
+// variantKey extends key with the request's values of the headers a
+// response varies on.
+func variantKey(key string, vary []string, r *http.Request) string {
+	var b strings.Builder
+	b.WriteString(key)
+	b.WriteString("\nvary")
+	for _, h := range vary {
+		b.WriteByte('\n')
+		b.WriteString(h)
+		b.WriteByte(':')
+		b.WriteString(strings.Join(r.Header.Values(h), ","))
+	}
+	return b.String()
+}
+
+// varyHeaders returns the canonical request header names listed in a
+// response's Vary header, and false if it contains "*".
+func varyHeaders(header http.Header) ([]string, bool) {
+	var names []string
+	seen := make(map[string]bool)
+	for _, v := range header.Values("Vary") {
+		for _, name := range strings.Split(v, ",") {
+			name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+			if name == "*" {
+				return nil, false
+			}
+			if name != "" && !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	return names, true
+}
+
+// isPublic reports whether a response is explicitly marked public.
+func isPublic(header http.Header) bool {
+	_, ok := parseCacheControl(header.Get("Cache-Control"))["public"]
+	return ok
+}
+
+// routeTags returns the route's cache tags for r, with path values expanded.
+func routeTags(r *http.Request) []string {
+	if route, ok := server.RouteFromContext(r.Context()); ok {
+		if t, ok := route.Metadata[cacheTagsKey].([]string); ok {
+			return expandTags(t, r)
+		}
+	}
+	return nil
+}
+
+// generation reads the tag generation of r's route before its response is
+// generated. It reports false if the store fails, in which case the
+// response is not stored.
+func (c *responseCache) generation(r *http.Request) (uint64, bool) {
+	tags := routeTags(r)
+	if len(tags) == 0 {
+		return 0, true
+	}
+	gen, err := c.opts.Store.TagGeneration(r.Context(), tags...)
+	if err != nil {
+		server.LoggerFromContext(r.Context()).Error("cache store: " + err.Error())
+		return 0, false
+	}
+	return gen, true
+}
+
+// store saves a response if it is cacheable. Responses to credentialed
+// requests and responses that set cookies must be marked public. gen is the
+// tag generation read before the response was generated; if its tags have
+// been invalidated since, the response is dropped.
+func (c *responseCache) store(r *http.Request, key string, credentialed bool, gen uint64, status int, header http.Header, body []byte) {
+	if status != http.StatusOK {
+		return
+	}
+	vary, ok := varyHeaders(header)
+	if !ok {
+		return
+	}
+	cc := parseCacheControl(header.Get("Cache-Control"))
+	for _, d := range []string{"no-store", "no-cache", "private"} {
+		if _, ok := cc[d]; ok {
+			return
+		}
+	}
+	if _, public := cc["public"]; !public && (credentialed || header.Get("Set-Cookie") != "") {
+		return
+	}
+	ttl := c.opts.TTL
+	stale := c.opts.StaleWhileRevalidate
+	tags := routeTags(r)
+	routeTTL, hasRouteTTL := time.Duration(0), false
+	if route, ok := server.RouteFromContext(r.Context()); ok {
+		routeTTL, hasRouteTTL = route.Metadata[cacheTTLKey].(time.Duration)
+	}
+	if s, ok := cacheSeconds(cc, "s-maxage"); ok {
+		ttl = s
+	} else if hasRouteTTL {
+		ttl = routeTTL
+	} else if s, ok := cacheSeconds(cc, "max-age"); ok {
+		ttl = s
+	}
+	if s, ok := cacheSeconds(cc, "stale-while-revalidate"); ok {
+		stale = s
+	}
+	if ttl <= 0 {
+		return
+	}
+	header = header.Clone()
+	for _, h := range perRequestHeaders {
+		header.Del(h)
+	}
+	entry := &cache.Entry{
+		Status: status,
+		Header: header,
+		Body:   append([]byte(nil), body...),
+		Tags:   tags,
+		Stored: time.Now(),
+		TTL:    ttl,
+		Stale:  stale,
+	}
+	ctx := r.Context()
+	if len(tags) > 0 {
+		if now, err := c.opts.Store.TagGeneration(ctx, tags...); err != nil || now != gen {
+			return
+		}
+	}
+	if len(vary) > 0 {
+		marker := &cache.Entry{Vary: vary, Tags: tags, Stored: entry.Stored, TTL: ttl, Stale: stale}
+		if err := c.opts.Store.Set(ctx, key, marker); err != nil {
+			server.LoggerFromContext(ctx).Error("cache store: " + err.Error())
+			return
+		}
+		key = variantKey(key, vary, r)
+	}
+	if err := c.opts.Store.Set(ctx, key, entry); err != nil {
+		server.LoggerFromContext(ctx).Error("cache store: " + err.Error())
+	}
+}
+
+// revalidate refreshes a stale entry in the background, at most once per key.
+func (c *responseCache) revalidate(next http.Handler, r *http.Request, key string, credentialed bool) {
+	c.mu.Lock()
+	if c.revalidating[key] {
+		c.mu.Unlock()
+		return
+	}
+	c.revalidating[key] = true
+	c.mu.Unlock()
+
+	req := r.Clone(context.WithoutCancel(r.Context()))
+	gen, ok := c.generation(req)
+	if !ok {
+		c.mu.Lock()
+		delete(c.revalidating, key)
+		c.mu.Unlock()
+		return
+	}
+	go func() {
+		defer func() {
+			if rec := recover(); rec != nil {
+				server.LoggerFromContext(req.Context()).Error("cache revalidation panic")
+			}
+			c.mu.Lock()
+			delete(c.revalidating, key)
+			c.mu.Unlock()
+		}()
+		rec := &recorder{header: make(http.Header), status: http.StatusOK}
+		next.ServeHTTP(rec, req)
+		c.store(req, key, credentialed, gen, rec.status, rec.header, rec.body.Bytes())
+	}()
+}
+
+// serveEntry writes a cached response.
+func serveEntry(w http.ResponseWriter, r *http.Request, e *cache.Entry, status string, now time.Time) {
+	h := w.Header()
+	for k, v := range e.Header {
+		h[k] = append([]string(nil), v...)
+	}
+	h.Set("X-Cache", status)
+	h.Set("Age", strconv.Itoa(int(now.Sub(e.Stored)/time.Second)))
+	w.WriteHeader(e.Status)
+	if r.Method != http.MethodHead {
+		w.Write(e.Body)
+	}
+}
+
+// parseCacheControl splits a Cache-Control header into lower-case
+// directives and their values.
+func parseCacheControl(v string) map[string]string {
+	directives := make(map[string]string)
+	for _, part := range strings.Split(v, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		if name != "" {
+			directives[strings.ToLower(name)] = strings.Trim(value, `"`)
+		}
+	}
+	return directives
+}
+
+// cacheSeconds returns a directive's value in seconds as a Duration.
+func cacheSeconds(cc map[string]string, name string) (time.Duration, bool) {
+	v, ok := cc[name]
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return time.Duration(n) * time.Second, true
+}
+
+// expandTags replaces {name} references in tags with the request's path
+// wildcard values.
+func expandTags(tags []string, r *http.Request) []string {
+	out := make([]string, len(tags))
+	for i, tag := range tags {
+		var b strings.Builder
+		for {
+			before, rest, ok := strings.Cut(tag, "{")
+			if !ok {
+				break
+			}
+			name, after, ok := strings.Cut(rest, "}")
+			if !ok {
+				break
+			}
+			b.WriteString(before)
+			b.WriteString(server.PathValue(r, name))
+			tag = after
+		}
+		b.WriteString(tag)
+		out[i] = b.String()
+	}
+	return out
+}
+
+// recorder is a minimal in-memory ResponseWriter for background requests.
+type recorder struct {
+	header      http.Header
+	status      int
+	body        bytes.Buffer
+	wroteHeader bool
+}
+
+func (w *recorder) Header() http.Header { return w.header }
+
+func (w *recorder) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.status = code
+	}
+}
+
+func (w *recorder) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.body.Write(b)
+}
@@ -0,0 +1,202 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/SailfinIO/sail/internal/cache"
+	"github.com/SailfinIO/sail/internal/server"
+)
+
+func TestCache(t *testing.T) {
+	type step struct {
+		header map[string]string
+		cached bool // The response comes from the cache.
+	}
+	tests := []struct {
+		name     string
+		response map[string]string
+		steps    []step
+	}{
+		{"anonymous", nil, []step{
+			{cached: false},
+			{cached: true},
+		}},
+		{"cookie request is not cached", nil, []step{
+			{header: map[string]string{"Cookie": "sid=alice"}, cached: false},
+			{header: map[string]string{"Cookie": "sid=alice"}, cached: false},
+		}},
+		{"cookie request is not served a shared response", nil, []step{
+			{cached: false},
+			{header: map[string]string{"Cookie": "sid=alice"}, cached: false},
+		}},
+		{"authorization request is not cached", nil, []step{
+			{header: map[string]string{"Authorization": "Bearer a"}, cached: false},
+			{header: map[string]string{"Authorization": "Bearer a"}, cached: false},
+		}},
+		{"public opts in", map[string]string{"Cache-Control": "public"}, []step{
+			{header: map[string]string{"Cookie": "sid=alice"}, cached: false},
+			{header: map[string]string{"Cookie": "sid=bob"}, cached: true},
+		}},
+		{"private", map[string]string{"Cache-Control": "private, max-age=60"}, []step{
+			{cached: false},
+			{cached: false},
+		}},
+		{"set-cookie", map[string]string{"Set-Cookie": "sid=new"}, []step{
+			{cached: false},
+			{cached: false},
+		}},
+		{"vary star", map[string]string{"Vary": "*"}, []step{
+			{cached: false},
+			{cached: false},
+		}},
+		{"vary selects a variant", map[string]string{"Vary": "Accept-Language"}, []step{
+			{header: map[string]string{"Accept-Language": "en"}, cached: false},
+			{header: map[string]string{"Accept-Language": "fr"}, cached: false},
+			{header: map[string]string{"Accept-Language": "en"}, cached: true},
+			{header: map[string]string{"Accept-Language": "fr"}, cached: true},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			h := Cache(CacheOptions{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				for k, v := range tt.response {
+					w.Header().Set(k, v)
+				}
+				fmt.Fprintf(w, "%s-%d", r.Header.Get("Accept-Language"), calls)
+			}))
+			for i, s := range tt.steps {
+				r := httptest.NewRequest(http.MethodGet, "/items", nil)
+				for k, v := range s.header {
+					r.Header.Set(k, v)
+				}
+				before := calls
+				w := httptest.NewRecorder()
+				h.ServeHTTP(w, r)
+				if cached := calls == before; cached != s.cached {
+					t.Fatalf("step %d: cached = %v, want %v (X-Cache %s)", i, cached, s.cached, w.Header().Get("X-Cache"))
+				}
+				if lang := s.header["Accept-Language"]; lang != "" && w.Body.String()[:len(lang)] != lang {
+					t.Fatalf("step %d: served %q for %s", i, w.Body.String(), lang)
+				}
+			}
+		})
+	}
+}
+
+func TestCacheStripsPerRequestHeaders(t *testing.T) {
+	h := RequestID(RequestIDOptions{})(Cache(CacheOptions{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", "Mon, 02 Jan 2006 15:04:05 GMT")
+		w.Header().Set("Set-Cookie", "sid=new")
+		w.Header().Set("Cache-Control", "public")
+		w.Header().Set("X-Kept", "yes")
+	})))
+	var ids []string
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		ids = append(ids, w.Header().Get("X-Request-Id"))
+		if i == 0 {
+			continue
+		}
+		if got := w.Header().Get("X-Cache"); got != "HIT" {
+			t.Fatalf("X-Cache = %q, want HIT", got)
+		}
+		for _, name := range []string{"Date", "Set-Cookie"} {
+			if v := w.Header().Get(name); v != "" {
+				t.Fatalf("replayed %s: %q", name, v)
+			}
+		}
+		if w.Header().Get("X-Kept") != "yes" {
+			t.Fatal("stored header X-Kept was dropped")
+		}
+	}
+	if ids[0] == ids[1] {
+		t.Fatalf("replayed request ID %q", ids[0])
+	}
+}
+
+func TestCacheTagsUsePathValues(t *testing.T) {
+	tests := []struct {
+		name   string
+		global bool
+	}{
+		{"route middleware", false},
+		{"global middleware", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := cache.NewMemoryStore(10)
+			router := server.NewRouter()
+			mw := Cache(CacheOptions{Store: store})
+			if tt.global {
+				router.UseGlobal(mw)
+			} else {
+				router.Use(mw)
+			}
+			calls := 0
+			router.Handle("GET /products/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+			}), CacheTags("product:{id}"))
+			get := func() {
+				router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/products/42", nil))
+			}
+			get()
+			get()
+			if err := store.InvalidateTags(context.Background(), "product:42"); err != nil {
+				t.Fatal(err)
+			}
+			get()
+			if calls != 2 {
+				t.Fatalf("handler ran %d times, want 2", calls)
+			}
+		})
+	}
+}
+
+func TestCacheKeyIncludesHost(t *testing.T) {
+	calls := 0
+	h := Cache(CacheOptions{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		fmt.Fprint(w, r.Host)
+	}))
+	for _, host := range []string{"a.example", "b.example", "A.example"} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Host = host
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if !strings.EqualFold(w.Body.String(), host) {
+			t.Fatalf("host %s served %q", host, w.Body.String())
+		}
+	}
+	if calls != 2 {
+		t.Fatalf("handler ran %d times, want 2", calls)
+	}
+}
+
+func TestCacheDropsResponseInvalidatedWhileGenerated(t *testing.T) {
+	store := cache.NewMemoryStore(10)
+	router := server.NewRouter()
+	router.Use(Cache(CacheOptions{Store: store}))
+	calls := 0
+	router.Handle("GET /products", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			// A write elsewhere invalidates the tag while this response,
+			// computed from the old data, is being generated.
+			store.InvalidateTags(r.Context(), "products")
+		}
+	}), CacheTags("products"))
+	for i := 0; i < 3; i++ {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/products", nil))
+	}
+	if calls != 2 {
+		t.Fatalf("handler ran %d times, want 2", calls)
+	}
+}
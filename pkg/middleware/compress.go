@@ -0,0 +1,291 @@
+package middleware
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Compressor is a compressing writer that can be reused for another
+// response after Reset. *gzip.Writer and *flate.Writer implement it, as do
+// the writers of common brotli and zstd packages.
+type Compressor interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// encoder is a registered content coding with its writer pool.
+type encoder struct {
+	name string
+	pool sync.Pool
+}
+
+// EncoderRegistry holds the content codings available to the Compress middleware.
+type EncoderRegistry struct {
+	mu       sync.RWMutex
+	encoders []*encoder // In order of server preference.
+}
+
+// NewEncoderRegistry creates a registry with gzip and deflate registered.
+func NewEncoderRegistry() *EncoderRegistry {
+	r := &EncoderRegistry{}
+	r.Register("deflate", func() Compressor {
+		w, _ := flate.NewWriter(io.Discard, flate.DefaultCompression)
+		return w
+	})
+	r.Register("gzip", func() Compressor {
+		return gzip.NewWriter(io.Discard)
+	})
+	return r
+}
+
+// Register adds a content coding such as "br" or "zstd". newCompressor
+// creates a writer configured with the desired compression level; writers
+// are pooled and Reset for each response.
+// Codings registered later are preferred when the client accepts several
+// with equal quality. Registering an existing coding replaces it.
+func (r *EncoderRegistry) Register(name string, newCompressor func() Compressor) {
+	e := &encoder{name: strings.ToLower(name)}
+	e.pool.New = func() interface{} { return newCompressor() }
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	encoders := []*encoder{e}
+	for _, existing := range r.encoders {
+		if existing.name != e.name {
+			encoders = append(encoders, existing)
+		}
+	}
+	r.encoders = encoders
+}
+
+// negotiate selects the preferred coding accepted by an Accept-Encoding header.
+func (r *EncoderRegistry) negotiate(header string) *encoder {
+	if header == "" {
+		return nil
+	}
+	accepted := make(map[string]float64)
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		accepted[strings.ToLower(strings.TrimSpace(name))] = q
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var best *encoder
+	bestQ := 0.0
+	for _, e := range r.encoders {
+		q, ok := accepted[e.name]
+		if !ok {
+			q, ok = accepted["*"]
+		}
+		if ok && q > bestQ {
+			best, bestQ = e, q
+		}
+	}
+	return best
+}
+
+// DefaultEncoders is the registry used when CompressOptions.Encoders is nil.
+var DefaultEncoders = NewEncoderRegistry()
+
+// defaultSkipContentTypes lists media types that are already compressed.
+var defaultSkipContentTypes = []string{
+	"image/", "video/", "audio/", "font/woff",
+	"application/zip", "application/gzip", "application/x-gzip",
+	"application/x-bzip2", "application/x-xz", "application/x-7z-compressed",
+	"application/x-rar-compressed", "application/zstd", "application/wasm",
+}
+
+// CompressOptions configures the Compress middleware.
+type CompressOptions struct {
+	// MinSize is the smallest body, in bytes, that is compressed. Defaults to 1024.
+	MinSize int
+	// SkipContentTypes lists media type prefixes that are never compressed.
+	// Defaults to common image, audio, video and archive types; SVG is always compressible.
+	SkipContentTypes []string
+	// Encoders holds the available codings. Defaults to DefaultEncoders.
+	Encoders *EncoderRegistry
+}
+
+// Compress returns a middleware that compresses responses using the coding
+// negotiated from Accept-Encoding. Responses are buffered until MinSize bytes
+// have been written so that small bodies are sent uncompressed.
+func Compress(opts CompressOptions) func(http.Handler) http.Handler {
+	if opts.MinSize <= 0 {
+		opts.MinSize = 1024
+	}
+	if opts.SkipContentTypes == nil {
+		opts.SkipContentTypes = defaultSkipContentTypes
+	}
+	if opts.Encoders == nil {
+		opts.Encoders = DefaultEncoders
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+			enc := opts.Encoders.negotiate(r.Header.Get("Accept-Encoding"))
+			if enc == nil || r.Method == http.MethodHead || r.Header.Get("Range") != "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			cw := &compressWriter{ResponseWriter: w, opts: &opts, enc: enc, status: http.StatusOK}
+			defer func() {
+				if p := recover(); p != nil {
+					// Leave the response unstarted so Recovery can send a 500.
+					cw.abort()
+					panic(p)
+				}
+				cw.close()
+			}()
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// compressWriter buffers the start of a response to decide whether to compress it.
+type compressWriter struct {
+	http.ResponseWriter
+	opts       *CompressOptions
+	enc        *encoder
+	status     int
+	buf        []byte
+	decided    bool
+	compressor Compressor
+}
+
+func (w *compressWriter) WriteHeader(code int) {
+	if w.decided {
+		return
+	}
+	if code >= 100 && code <= 199 {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	w.status = code
+	if !bodyAllowed(code) {
+		w.decide(false)
+	}
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if !w.decided {
+		w.buf = append(w.buf, b...)
+		if len(w.buf) < w.opts.MinSize {
+			return len(b), nil
+		}
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+	if w.compressor != nil {
+		return w.compressor.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush sends buffered data. A response flushed before MinSize is reached
+// is treated as a stream and compressed if its type allows.
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.decide(true)
+	}
+	if w.compressor != nil {
+		w.compressor.Flush()
+	}
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController.
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// decide writes the header, compressing if large is set and the response is eligible,
+// then writes any buffered data.
+func (w *compressWriter) decide(large bool) error {
+	w.decided = true
+	h := w.Header()
+	if h.Get("Content-Type") == "" && len(w.buf) > 0 {
+		h.Set("Content-Type", http.DetectContentType(w.buf))
+	}
+	if large && bodyAllowed(w.status) && h.Get("Content-Encoding") == "" && w.compressible(h.Get("Content-Type")) {
+		h.Del("Content-Length")
+		h.Set("Content-Encoding", w.enc.name)
+		if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			// The compressed representation differs byte-for-byte.
+			h.Set("ETag", "W/"+etag)
+		}
+		w.compressor = w.enc.pool.Get().(Compressor)
+		w.compressor.Reset(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	if len(w.buf) == 0 {
+		return nil
+	}
+	var err error
+	if w.compressor != nil {
+		_, err = w.compressor.Write(w.buf)
+	} else {
+		_, err = w.ResponseWriter.Write(w.buf)
+	}
+	w.buf = nil
+	return err
+}
+
+// close completes the response and returns the compressor to its pool.
+func (w *compressWriter) close() {
+	if !w.decided {
+		w.decide(false)
+	}
+	if w.compressor != nil {
+		w.compressor.Close()
+		w.compressor.Reset(io.Discard)
+		w.enc.pool.Put(w.compressor)
+		w.compressor = nil
+	}
+}
+
+// abort discards buffered data after a panic and releases the compressor
+// without completing the response.
+func (w *compressWriter) abort() {
+	w.buf = nil
+	if w.compressor != nil {
+		w.compressor.Reset(io.Discard)
+		w.enc.pool.Put(w.compressor)
+		w.compressor = nil
+	}
+}
+
+// compressible reports whether a response of the given type should be compressed.
+func (w *compressWriter) compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.ToLower(contentType)
+	}
+	if mediaType == "image/svg+xml" {
+		return true
+	}
+	for _, skip := range w.opts.SkipContentTypes {
+		if strings.HasPrefix(mediaType, skip) {
+			return false
+		}
+	}
+	return true
+}
+
+// bodyAllowed reports whether a response with the given status may have a body.
+func bodyAllowed(status int) bool {
+	return status != http.StatusNoContent && status != http.StatusNotModified && (status < 100 || status > 199)
+}
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCompress(t *testing.T) {
+	large := strings.Repeat("sail ", 400)
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		contentType    string
+		body           string
+		encoding       string
+	}{
+		{"gzip", "gzip", "text/plain", large, "gzip"},
+		{"small body", "gzip", "text/plain", "tiny", ""},
+		{"not accepted", "", "text/plain", large, ""},
+		{"already compressed type", "gzip", "image/png", large, ""},
+		{"svg", "gzip", "image/svg+xml", large, "gzip"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := Compress(CompressOptions{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				io.WriteString(w, tt.body)
+			}))
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if got := w.Header().Get("Content-Encoding"); got != tt.encoding {
+				t.Fatalf("Content-Encoding = %q, want %q", got, tt.encoding)
+			}
+			body := w.Body.String()
+			if tt.encoding == "gzip" {
+				zr, err := gzip.NewReader(w.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				b, _ := io.ReadAll(zr)
+				body = string(b)
+			}
+			if body != tt.body {
+				t.Fatalf("body mismatch: got %d bytes, want %d", len(body), len(tt.body))
+			}
+		})
+	}
+}
+
+func TestCompressPanicReachesRecovery(t *testing.T) {
+	tests := []struct {
+		name  string
+		write string
+	}{
+		{"buffered partial body", "partial"},
+		{"nothing written", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := Recovery(RecoveryOptions{DisableStack: true})(Compress(CompressOptions{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				io.WriteString(w, tt.write)
+				panic("boom")
+			})))
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Accept-Encoding", "gzip")
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != http.StatusInternalServerError || strings.Contains(w.Body.String(), "partial") {
+				t.Fatalf("status = %d, body = %q", w.Code, w.Body.String())
+			}
+		})
+	}
+}
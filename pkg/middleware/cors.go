@@ -1,17 +1,254 @@
 package middleware
 
-import "net/http"
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
-// CORS is a simple middleware to handle CORS headers.
+	"github.com/SailfinIO/sail/internal/server"
+)
+
+// RouteMatcher reports which route would serve a request. *sail.Router
+// implements it; the CORS middleware uses it to reject preflight requests
+// for routes that do not exist.
+type RouteMatcher interface {
+	Handler(r *http.Request) (http.Handler, string)
+}
+
+// CORSOptions configures the CORS middleware.
+type CORSOptions struct {
+	// AllowedOrigins lists origins allowed to make cross-origin requests.
+	// "*" allows any origin and "https://*.example.com" allows subdomains.
+	AllowedOrigins []string
+	// AllowedOriginPatterns allows origins matching any of the expressions.
+	AllowedOriginPatterns []*regexp.Regexp
+	// AllowOriginFunc, if set, is consulted for origins not otherwise allowed.
+	AllowOriginFunc func(origin string, r *http.Request) bool
+	// AllowedMethods lists the methods allowed in preflight requests.
+	AllowedMethods []string
+	// AllowedHeaders lists the request headers allowed in preflight requests.
+	// "*" allows any header.
+	AllowedHeaders []string
+	// ExposedHeaders lists response headers readable by the client.
+	ExposedHeaders []string
+	// AllowCredentials allows cookies and HTTP authentication. It cannot be
+	// combined with the "*" origin, which would let any site read
+	// credentialed responses; list the trusted origins instead.
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache preflight results. Zero omits the header.
+	MaxAge time.Duration
+	// Routes is used to pass preflight requests for unknown routes on to the
+	// router, which answers them with 404 or 405. It defaults to the Router
+	// serving the request.
+	Routes RouteMatcher
+}
+
+// DefaultCORSOptions returns permissive options allowing any origin.
+func DefaultCORSOptions() CORSOptions {
+	return CORSOptions{
+		AllowedOrigins: []string{"*"},
+		AllowedMethods: []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodOptions},
+		AllowedHeaders: []string{"Content-Type", "Authorization"},
+	}
+}
+
+// CORS is a simple middleware to handle CORS headers using DefaultCORSOptions.
 func CORS(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-		if r.Method == http.MethodOptions {
-			w.WriteHeader(http.StatusOK)
-			return
+	return NewCORS(DefaultCORSOptions())(next)
+}
+
+// cors holds the normalized CORS configuration.
+type cors struct {
+	opts           CORSOptions
+	anyOrigin      bool
+	origins        map[string]bool
+	wildcards      []string // Origin suffix patterns, e.g. "https://" + ".example.com".
+	methods        map[string]bool
+	anyHeader      bool
+	headers        map[string]bool
+	allowMethods   string
+	allowHeaders   string
+	exposedHeaders string
+	maxAge         string
+}
+
+// NewCORS returns a CORS middleware configured by opts.
+// For preflight handling on method-specific routes, register it with
+// Router.UseGlobal so it runs before routing. It panics if AllowCredentials
+// is combined with the "*" origin.
+func NewCORS(opts CORSOptions) func(http.Handler) http.Handler {
+	c := &cors{
+		opts:    opts,
+		origins: make(map[string]bool),
+		methods: make(map[string]bool),
+		headers: make(map[string]bool),
+	}
+	for _, o := range opts.AllowedOrigins {
+		o = strings.ToLower(o)
+		switch {
+		case o == "*":
+			c.anyOrigin = true
+		case strings.Contains(o, "*"):
+			c.wildcards = append(c.wildcards, o)
+		default:
+			c.origins[o] = true
+		}
+	}
+	if c.anyOrigin && opts.AllowCredentials {
+		panic(`middleware: CORS AllowCredentials cannot be used with the "*" origin`)
+	}
+	for _, m := range opts.AllowedMethods {
+		c.methods[strings.ToUpper(m)] = true
+	}
+	for _, h := range opts.AllowedHeaders {
+		if h == "*" {
+			c.anyHeader = true
+			continue
 		}
+		c.headers[http.CanonicalHeaderKey(h)] = true
+	}
+	c.allowMethods = strings.Join(opts.AllowedMethods, ", ")
+	c.allowHeaders = strings.Join(opts.AllowedHeaders, ", ")
+	c.exposedHeaders = strings.Join(opts.ExposedHeaders, ", ")
+	if opts.MaxAge > 0 {
+		c.maxAge = strconv.Itoa(int(opts.MaxAge / time.Second))
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if isPreflight(r) {
+				c.handlePreflight(w, r, next)
+				return
+			}
+			c.handleActual(w, r)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// isPreflight reports whether r is a CORS preflight request.
+func isPreflight(r *http.Request) bool {
+	return r.Method == http.MethodOptions &&
+		r.Header.Get("Origin") != "" &&
+		r.Header.Get("Access-Control-Request-Method") != ""
+}
+
+// handlePreflight validates a preflight request and answers it.
+func (c *cors) handlePreflight(w http.ResponseWriter, r *http.Request, next http.Handler) {
+	h := w.Header()
+	h.Add("Vary", "Origin")
+	h.Add("Vary", "Access-Control-Request-Method")
+	h.Add("Vary", "Access-Control-Request-Headers")
+
+	origin := r.Header.Get("Origin")
+	method := strings.ToUpper(r.Header.Get("Access-Control-Request-Method"))
+	if routes := c.routes(r); routes != nil && !routeExists(routes, r, method) {
 		next.ServeHTTP(w, r)
-	})
+		return
+	}
+	if !c.originAllowed(origin, r) || !c.methods[method] {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	requested := parseHeaderList(r.Header.Get("Access-Control-Request-Headers"))
+	if !c.anyHeader {
+		for _, name := range requested {
+			if !c.headers[name] {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+		}
+	}
+
+	c.setOrigin(h, origin)
+	h.Set("Access-Control-Allow-Methods", c.allowMethods)
+	if c.anyHeader {
+		if len(requested) > 0 {
+			h.Set("Access-Control-Allow-Headers", strings.Join(requested, ", "))
+		}
+	} else if c.allowHeaders != "" {
+		h.Set("Access-Control-Allow-Headers", c.allowHeaders)
+	}
+	if c.maxAge != "" {
+		h.Set("Access-Control-Max-Age", c.maxAge)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleActual adds CORS headers to a non-preflight request.
+func (c *cors) handleActual(w http.ResponseWriter, r *http.Request) {
+	h := w.Header()
+	h.Add("Vary", "Origin")
+	origin := r.Header.Get("Origin")
+	if origin == "" || !c.originAllowed(origin, r) {
+		return
+	}
+	c.setOrigin(h, origin)
+	if c.exposedHeaders != "" {
+		h.Set("Access-Control-Expose-Headers", c.exposedHeaders)
+	}
+}
+
+// setOrigin writes Access-Control-Allow-Origin and, if enabled,
+// Access-Control-Allow-Credentials.
+func (c *cors) setOrigin(h http.Header, origin string) {
+	if c.anyOrigin {
+		h.Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+	h.Set("Access-Control-Allow-Origin", origin)
+	if c.opts.AllowCredentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// originAllowed reports whether origin may access the resource.
+func (c *cors) originAllowed(origin string, r *http.Request) bool {
+	lower := strings.ToLower(origin)
+	if c.anyOrigin || c.origins[lower] {
+		return true
+	}
+	for _, w := range c.wildcards {
+		prefix, suffix, _ := strings.Cut(w, "*")
+		if len(lower) > len(prefix)+len(suffix) && strings.HasPrefix(lower, prefix) && strings.HasSuffix(lower, suffix) {
+			return true
+		}
+	}
+	for _, re := range c.opts.AllowedOriginPatterns {
+		if re.MatchString(origin) {
+			return true
+		}
+	}
+	return c.opts.AllowOriginFunc != nil && c.opts.AllowOriginFunc(origin, r)
+}
+
+// routes returns the configured RouteMatcher or the Router serving r.
+func (c *cors) routes(r *http.Request) RouteMatcher {
+	if c.opts.Routes != nil {
+		return c.opts.Routes
+	}
+	if router, ok := server.RouterFromContext(r.Context()); ok {
+		return router
+	}
+	return nil
+}
+
+// routeExists reports whether a route serves r's path for method.
+func routeExists(routes RouteMatcher, r *http.Request, method string) bool {
+	probe := r.Clone(r.Context())
+	probe.Method = method
+	_, pattern := routes.Handler(probe)
+	return pattern != ""
+}
+
+// parseHeaderList splits a comma-separated header list into canonical names.
+func parseHeaderList(v string) []string {
+	var names []string
+	for _, name := range strings.Split(v, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, http.CanonicalHeaderKey(name))
+		}
+	}
+	return names
 }
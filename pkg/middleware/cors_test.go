@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/SailfinIO/sail/internal/server"
+)
+
+func TestCORSPreflight(t *testing.T) {
+	router := server.NewRouter()
+	router.UseGlobal(CORS)
+	router.Handle("GET /items", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	router.Handle("PATCH /items", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		name   string
+		path   string
+		method string
+		origin string
+		status int
+		allow  string
+	}{
+		{"known route", "/items", http.MethodGet, "https://a.example", http.StatusNoContent, "*"},
+		{"unknown route", "/missing", http.MethodGet, "https://a.example", http.StatusNotFound, ""},
+		{"method not routed", "/items", http.MethodDelete, "https://a.example", http.StatusMethodNotAllowed, ""},
+		{"method not allowed by CORS", "/items", http.MethodPatch, "https://a.example", http.StatusForbidden, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodOptions, tt.path, nil)
+			r.Header.Set("Origin", tt.origin)
+			r.Header.Set("Access-Control-Request-Method", tt.method)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.allow {
+				t.Fatalf("Access-Control-Allow-Origin = %q, want %q", got, tt.allow)
+			}
+		})
+	}
+}
+
+func TestCORSOrigins(t *testing.T) {
+	mw := NewCORS(CORSOptions{
+		AllowedOrigins:   []string{"https://app.example", "https://*.example.org"},
+		AllowedMethods:   []string{http.MethodGet},
+		AllowCredentials: true,
+	})
+	h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	tests := []struct {
+		origin string
+		allow  string
+	}{
+		{"https://app.example", "https://app.example"},
+		{"https://api.example.org", "https://api.example.org"},
+		{"https://example.org", ""},
+		{"https://evil.example", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.origin, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Origin", tt.origin)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.allow {
+				t.Fatalf("Access-Control-Allow-Origin = %q, want %q", got, tt.allow)
+			}
+		})
+	}
+}
+
+func TestNewCORSRejectsCredentialedWildcard(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  CORSOptions
+		panic bool
+	}{
+		{"wildcard with credentials", CORSOptions{AllowedOrigins: []string{"*"}, AllowCredentials: true}, true},
+		{"wildcard", CORSOptions{AllowedOrigins: []string{"*"}}, false},
+		{"listed origin with credentials", CORSOptions{AllowedOrigins: []string{"https://app.example"}, AllowCredentials: true}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if panicked := recover() != nil; panicked != tt.panic {
+					t.Fatalf("panicked = %v, want %v", panicked, tt.panic)
+				}
+			}()
+			NewCORS(tt.opts)
+		})
+	}
+}
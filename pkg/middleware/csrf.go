@@ -0,0 +1,340 @@
+package middleware
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SailfinIO/sail/internal/server"
+)
+
+// CSRFMode selects how CSRF tokens are stored.
+type CSRFMode int
+
+const (
+	// CSRFDoubleSubmit keeps the token in a cookie that the client must echo
+	// in a header or form field. Tokens are HMAC-signed when a Secret is set.
+	CSRFDoubleSubmit CSRFMode = iota
+	// CSRFSynchronizer keeps the token server-side in a CSRFStore, keyed by session.
+	CSRFSynchronizer
+)
+
+// csrfExemptKey is the route metadata key set by CSRFExempt.
+const csrfExemptKey = "csrf.exempt"
+
+// CSRFExempt is a route option that disables CSRF checks for a route,
+// e.g. a webhook authenticated by other means. It only takes effect when
+// CSRF runs after routing, i.e. is applied with Use or to a route group;
+// under UseGlobal the route is not known yet, so use ExcludePaths instead.
+func CSRFExempt() server.RouteOption {
+	return server.SetMetadata(csrfExemptKey, true)
+}
+
+// CSRFStore holds synchronizer tokens per session.
+type CSRFStore interface {
+	Get(ctx context.Context, sessionID string) (string, error)
+	Set(ctx context.Context, sessionID, token string) error
+}
+
+// CSRFOptions configures the CSRF middleware.
+type CSRFOptions struct {
+	// Mode selects double-submit cookies (default) or synchronizer tokens.
+	Mode CSRFMode
+	// Secret signs double-submit tokens, so that only tokens issued by this
+	// server are accepted. The signature also covers SessionID when it is
+	// set; only then does it stop a sibling subdomain that can set cookies
+	// from planting a valid token of its own. Strongly recommended.
+	Secret []byte
+	// CookieName names the token cookie (double-submit) or the session ID
+	// cookie (synchronizer without SessionID). Defaults to "_csrf".
+	CookieName string
+	// CookiePath defaults to "/".
+	CookiePath   string
+	CookieDomain string
+	// CookieSecure marks the cookie Secure; enable it when serving over HTTPS.
+	CookieSecure bool
+	// CookieSameSite defaults to http.SameSiteLaxMode.
+	CookieSameSite http.SameSite
+	// HeaderName carries the token on unsafe requests. Defaults to "X-CSRF-Token".
+	HeaderName string
+	// FormField carries the token in form submissions. Defaults to "_csrf".
+	FormField string
+	// ExcludePaths lists paths that are not checked, e.g. "/api/*".
+	// A trailing "*" matches any path with the given prefix.
+	ExcludePaths []string
+	// Exclude, if set, skips the check when it returns true.
+	Exclude func(r *http.Request) bool
+	// Store holds synchronizer tokens. Defaults to a MemoryCSRFStore.
+	Store CSRFStore
+	// SessionID identifies the client's session, e.g. the ID of its sail
+	// session once logged in. It must be stable across requests. In
+	// synchronizer mode it keys the Store and defaults to a random ID kept in
+	// the CookieName cookie; an empty ID means no token can be issued. In
+	// double-submit mode it binds signed tokens to the session.
+	SessionID func(r *http.Request) string
+}
+
+// CSRF returns a middleware that rejects unsafe requests (POST, PUT, PATCH,
+// DELETE, ...) without a valid CSRF token with 403 Forbidden. Safe methods,
+// excluded paths and routes registered with CSRFExempt are not checked.
+// The token for the current request is available via CSRFToken. In
+// synchronizer mode a new session's token, and its session cookie, are only
+// created when CSRFToken is first called, so call it before writing the
+// response.
+func CSRF(opts CSRFOptions) func(http.Handler) http.Handler {
+	if opts.CookieName == "" {
+		opts.CookieName = "_csrf"
+	}
+	if opts.CookiePath == "" {
+		opts.CookiePath = "/"
+	}
+	if opts.CookieSameSite == 0 {
+		opts.CookieSameSite = http.SameSiteLaxMode
+	}
+	if opts.HeaderName == "" {
+		opts.HeaderName = "X-CSRF-Token"
+	}
+	if opts.FormField == "" {
+		opts.FormField = "_csrf"
+	}
+	if opts.Mode == CSRFSynchronizer && opts.Store == nil {
+		opts.Store = NewMemoryCSRFStore(24 * time.Hour)
+	}
+	c := &csrf{opts: opts}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, err := c.current(r)
+			if err != nil {
+				server.LoggerFromContext(r.Context()).Error("CSRF token error: " + err.Error())
+				server.WriteError(w, err)
+				return
+			}
+			if token == "" && c.opts.Mode == CSRFDoubleSubmit {
+				// Clients read the cookie, so it is always issued.
+				token = c.issueCookie(w, r)
+			}
+			if token != "" {
+				r = r.WithContext(server.WithCSRFToken(r.Context(), token))
+			} else {
+				r = r.WithContext(server.WithCSRFTokenFunc(r.Context(), c.lazyToken(w, r)))
+			}
+			if !c.skip(r) && (token == "" || !c.valid(r, token)) {
+				server.WriteError(w, &server.HTTPError{
+					Status:  http.StatusForbidden,
+					Code:    "csrf_invalid",
+					Message: "Invalid or missing CSRF token",
+				})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// CSRFToken returns the CSRF token for r, for embedding in forms.
+func CSRFToken(r *http.Request) string {
+	return server.CSRFTokenFromContext(r.Context())
+}
+
+// csrf holds the normalized configuration.
+type csrf struct {
+	opts CSRFOptions
+}
+
+// current returns the client's existing token, or an empty string if it
+// has none. It does not issue tokens.
+func (c *csrf) current(r *http.Request) (string, error) {
+	if c.opts.Mode == CSRFSynchronizer {
+		sid := c.sessionID(r)
+		if sid == "" {
+			return "", nil
+		}
+		return c.opts.Store.Get(r.Context(), sid)
+	}
+	if cookie, err := r.Cookie(c.opts.CookieName); err == nil && c.wellFormed(r, cookie.Value) {
+		return cookie.Value, nil
+	}
+	return "", nil
+}
+
+// issueCookie creates a double-submit token and sets its cookie.
+func (c *csrf) issueCookie(w http.ResponseWriter, r *http.Request) string {
+	token := randomToken()
+	if c.opts.Secret != nil {
+		token += "." + c.sign(r, token)
+	}
+	c.setCookie(w, token)
+	return token
+}
+
+// lazyToken returns a function that issues a synchronizer token on its
+// first call, so that anonymous requests that never render a form do not
+// create sessions.
+func (c *csrf) lazyToken(w http.ResponseWriter, r *http.Request) func() string {
+	var (
+		once  sync.Once
+		token string
+	)
+	return func() string {
+		once.Do(func() {
+			sid := c.sessionID(r)
+			if sid == "" {
+				if c.opts.SessionID != nil {
+					return
+				}
+				sid = c.newSessionID(w, r)
+			}
+			t := randomToken()
+			if err := c.opts.Store.Set(r.Context(), sid, t); err != nil {
+				server.LoggerFromContext(r.Context()).Error("CSRF token error: " + err.Error())
+				return
+			}
+			token = t
+		})
+		return token
+	}
+}
+
+// sessionID returns the session ID, or an empty string if there is none.
+func (c *csrf) sessionID(r *http.Request) string {
+	if c.opts.SessionID != nil {
+		return c.opts.SessionID(r)
+	}
+	if c.opts.Mode == CSRFSynchronizer {
+		if cookie, err := r.Cookie(c.opts.CookieName); err == nil {
+			return cookie.Value
+		}
+	}
+	return ""
+}
+
+// newSessionID issues a synchronizer session ID cookie.
+func (c *csrf) newSessionID(w http.ResponseWriter, r *http.Request) string {
+	sid := randomToken()
+	c.setCookie(w, sid)
+	// Make the new ID visible to later reads within this request.
+	r.AddCookie(&http.Cookie{Name: c.opts.CookieName, Value: sid})
+	return sid
+}
+
+// setCookie writes the CSRF cookie.
+func (c *csrf) setCookie(w http.ResponseWriter, value string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     c.opts.CookieName,
+		Value:    value,
+		Path:     c.opts.CookiePath,
+		Domain:   c.opts.CookieDomain,
+		Secure:   c.opts.CookieSecure,
+		HttpOnly: c.opts.Mode == CSRFSynchronizer,
+		SameSite: c.opts.CookieSameSite,
+	})
+}
+
+// wellFormed reports whether a double-submit cookie value can be used,
+// verifying its signature when a Secret is set.
+func (c *csrf) wellFormed(r *http.Request, token string) bool {
+	if token == "" {
+		return false
+	}
+	if c.opts.Secret == nil {
+		return true
+	}
+	value, sig, ok := strings.Cut(token, ".")
+	return ok && hmac.Equal([]byte(sig), []byte(c.sign(r, value)))
+}
+
+// sign returns the HMAC of a token value, bound to the session ID if any.
+func (c *csrf) sign(r *http.Request, value string) string {
+	mac := hmac.New(sha256.New, c.opts.Secret)
+	sid := c.sessionID(r)
+	mac.Write([]byte(strconv.Itoa(len(sid)) + ":" + sid))
+	mac.Write([]byte(value))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// skip reports whether r is exempt from the check.
+func (c *csrf) skip(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	if route, ok := server.RouteFromContext(r.Context()); ok {
+		if exempt, _ := route.Metadata[csrfExemptKey].(bool); exempt {
+			return true
+		}
+	}
+	return skipPath(c.opts.ExcludePaths, r.URL.Path) || (c.opts.Exclude != nil && c.opts.Exclude(r))
+}
+
+// valid reports whether r carries the expected token.
+func (c *csrf) valid(r *http.Request, expected string) bool {
+	sent := r.Header.Get(c.opts.HeaderName)
+	if sent == "" {
+		sent = r.PostFormValue(c.opts.FormField)
+	}
+	return sent != "" && subtle.ConstantTimeCompare([]byte(sent), []byte(expected)) == 1
+}
+
+// randomToken returns 32 random bytes, base64url-encoded.
+func randomToken() string {
+	var b [32]byte
+	rand.Read(b[:])
+	return base64.RawURLEncoding.EncodeToString(b[:])
+}
+
+// csrfEntry is a stored synchronizer token.
+type csrfEntry struct {
+	token   string
+	expires time.Time
+}
+
+// MemoryCSRFStore is an in-process CSRFStore whose tokens expire after a TTL.
+type MemoryCSRFStore struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	entries   map[string]csrfEntry
+	lastSweep time.Time
+}
+
+// NewMemoryCSRFStore creates a store whose tokens expire ttl after they are last used.
+func NewMemoryCSRFStore(ttl time.Duration) *MemoryCSRFStore {
+	return &MemoryCSRFStore{ttl: ttl, entries: make(map[string]csrfEntry)}
+}
+
+// Get implements CSRFStore.
+func (s *MemoryCSRFStore) Get(_ context.Context, sessionID string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if now.Sub(s.lastSweep) > s.ttl {
+		s.lastSweep = now
+		for id, e := range s.entries {
+			if now.After(e.expires) {
+				delete(s.entries, id)
+			}
+		}
+	}
+	e, ok := s.entries[sessionID]
+	if !ok || now.After(e.expires) {
+		return "", nil
+	}
+	e.expires = now.Add(s.ttl)
+	s.entries[sessionID] = e
+	return e.token, nil
+}
+
+// Set implements CSRFStore.
+func (s *MemoryCSRFStore) Set(_ context.Context, sessionID, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[sessionID] = csrfEntry{token: token, expires: time.Now().Add(s.ttl)}
+	return nil
+}
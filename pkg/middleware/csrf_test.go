@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SailfinIO/sail/internal/server"
+)
+
+func TestCSRF(t *testing.T) {
+	secret := []byte("csrf-secret")
+	issued := func(token string) string { return token }
+	tests := []struct {
+		name   string
+		opts   CSRFOptions
+		method string
+		path   string
+		send   func(token string) string // Token sent back, from the one issued on a GET.
+		form   bool                      // Send the token as a form field.
+		cookie string                    // Replaces the issued cookie, if set.
+		status int
+	}{
+		{"safe method", CSRFOptions{}, http.MethodGet, "/", nil, false, "", http.StatusOK},
+		{"missing token", CSRFOptions{}, http.MethodPost, "/", nil, false, "", http.StatusForbidden},
+		{"header token", CSRFOptions{Secret: secret}, http.MethodPost, "/", issued, false, "", http.StatusOK},
+		{"form token", CSRFOptions{Secret: secret}, http.MethodPost, "/", issued, true, "", http.StatusOK},
+		{"wrong token", CSRFOptions{Secret: secret}, http.MethodDelete, "/", func(token string) string { return token + "x" }, false, "", http.StatusForbidden},
+		{"forged unsigned cookie", CSRFOptions{Secret: secret}, http.MethodPost, "/", func(string) string { return "forged" }, false, "forged", http.StatusForbidden},
+		{"unsigned cookie without secret", CSRFOptions{}, http.MethodPost, "/", func(string) string { return "chosen" }, false, "chosen", http.StatusOK},
+		{"excluded path", CSRFOptions{ExcludePaths: []string{"/hooks/*"}}, http.MethodPost, "/hooks/github", nil, false, "", http.StatusOK},
+		{"synchronizer", CSRFOptions{Mode: CSRFSynchronizer}, http.MethodPost, "/", issued, false, "", http.StatusOK},
+		{"synchronizer wrong token", CSRFOptions{Mode: CSRFSynchronizer}, http.MethodPost, "/", func(string) string { return randomToken() }, false, "", http.StatusForbidden},
+		{"synchronizer new session", CSRFOptions{Mode: CSRFSynchronizer}, http.MethodPost, "/", issued, false, "other-session", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var token string
+			h := CSRF(tt.opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				token = CSRFToken(r)
+			}))
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			cookie := w.Result().Cookies()[0]
+			if tt.cookie != "" {
+				cookie.Value = tt.cookie
+			}
+
+			sent := ""
+			if tt.send != nil {
+				sent = tt.send(token)
+			}
+			var r *http.Request
+			if tt.form {
+				r = httptest.NewRequest(tt.method, tt.path, strings.NewReader(url.Values{"_csrf": {sent}}.Encode()))
+				r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			} else {
+				r = httptest.NewRequest(tt.method, tt.path, nil)
+				if sent != "" {
+					r.Header.Set("X-CSRF-Token", sent)
+				}
+			}
+			r.AddCookie(cookie)
+			w = httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d (%s)", w.Code, tt.status, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestCSRFExemptRoute(t *testing.T) {
+	router := server.NewRouter()
+	router.Use(CSRF(CSRFOptions{}))
+	router.Handle("POST /webhook", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), CSRFExempt())
+	router.Handle("POST /form", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	for path, want := range map[string]int{"/webhook": http.StatusOK, "/form": http.StatusForbidden} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, nil))
+		if w.Code != want {
+			t.Fatalf("%s: status = %d, want %d", path, w.Code, want)
+		}
+	}
+}
+
+func TestCSRFSessionBoundToken(t *testing.T) {
+	var token string
+	h := CSRF(CSRFOptions{Secret: []byte("csrf-secret"), SessionID: func(r *http.Request) string {
+		return r.Header.Get("X-Session")
+	}})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token = CSRFToken(r)
+	}))
+	// An attacker obtains a signed token for their own session and plants it.
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Session", "attacker")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	planted := w.Result().Cookies()[0]
+	for session, want := range map[string]int{"attacker": http.StatusOK, "victim": http.StatusForbidden} {
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		r.Header.Set("X-Session", session)
+		r.Header.Set("X-CSRF-Token", token)
+		r.AddCookie(planted)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != want {
+			t.Fatalf("session %s: status = %d, want %d", session, w.Code, want)
+		}
+	}
+}
+
+func TestCSRFSynchronizerIssuesLazily(t *testing.T) {
+	store := NewMemoryCSRFStore(time.Hour)
+	render := false
+	h := CSRF(CSRFOptions{Mode: CSRFSynchronizer, Store: store})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if render {
+			CSRFToken(r)
+		}
+	}))
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(method, "/", nil))
+		if len(w.Result().Cookies()) != 0 || len(store.entries) != 0 {
+			t.Fatalf("%s without a token created a session: cookies %v, entries %d", method, w.Result().Cookies(), len(store.entries))
+		}
+	}
+	render = true
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if len(w.Result().Cookies()) != 1 || len(store.entries) != 1 {
+		t.Fatalf("rendering a token: cookies %v, entries %d", w.Result().Cookies(), len(store.entries))
+	}
+}
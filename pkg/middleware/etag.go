@@ -0,0 +1,149 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"time"
+
+	"github.com/SailfinIO/sail/internal/server"
+)
+
+// ETagOptions configures the ETag middleware.
+type ETagOptions struct {
+	// Weak generates weak ETags (W/"...") instead of strong ones.
+	Weak bool
+	// ResourceETag, if set, returns the current ETag of the resource targeted
+	// by a PUT, PATCH or DELETE request so that If-Match and If-None-Match can
+	// be checked before the handler runs. Unquoted tags are treated as strong
+	// tags. An empty tag means the resource does not exist. Handlers can instead call sail.Context.CheckPreconditions.
+	ResourceETag func(r *http.Request) (string, error)
+	// RequireIfMatch rejects unsafe requests without If-Match with
+	// 428 Precondition Required. It requires ResourceETag.
+	RequireIfMatch bool
+}
+
+// ETag returns a middleware that adds ETags to successful GET and HEAD
+// responses and answers conditional requests with 304 Not Modified.
+// Responses are buffered to compute the tag; an ETag or Last-Modified
+// header set by the handler is used as is.
+func ETag(opts ETagOptions) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet, http.MethodHead:
+			case http.MethodPut, http.MethodPatch, http.MethodDelete:
+				if opts.ResourceETag != nil {
+					if err := checkResource(r, opts); err != nil {
+						server.WriteError(w, err)
+						return
+					}
+				}
+				next.ServeHTTP(w, r)
+				return
+			default:
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			bw := &bufferedWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(bw, r)
+			if bw.streaming {
+				return
+			}
+			h := w.Header()
+			if bw.status != http.StatusOK {
+				bw.flushBuffered()
+				return
+			}
+			etag := h.Get("ETag")
+			if etag == "" {
+				sum := sha256.Sum256(bw.buf.Bytes())
+				etag = server.FormatETag(base64.RawURLEncoding.EncodeToString(sum[:18]), opts.Weak)
+				h.Set("ETag", etag)
+			}
+			var lastModified time.Time
+			if lm := h.Get("Last-Modified"); lm != "" {
+				lastModified, _ = http.ParseTime(lm)
+			}
+			if server.NotModified(r, etag, lastModified) {
+				for _, k := range []string{"Content-Type", "Content-Length", "Content-Encoding"} {
+					h.Del(k)
+				}
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			bw.flushBuffered()
+		})
+	}
+}
+
+// checkResource evaluates preconditions of an unsafe request before its handler runs.
+func checkResource(r *http.Request, opts ETagOptions) error {
+	if opts.RequireIfMatch && r.Header.Get("If-Match") == "" {
+		return &server.HTTPError{
+			Status:  http.StatusPreconditionRequired,
+			Code:    "precondition_required",
+			Message: "This request requires an If-Match header",
+		}
+	}
+	if r.Header.Get("If-Match") == "" && r.Header.Get("If-None-Match") == "" {
+		return nil
+	}
+	etag, err := opts.ResourceETag(r)
+	if err != nil {
+		return err
+	}
+	return server.CheckPreconditions(r, server.FormatETag(etag, false), time.Time{})
+}
+
+// bufferedWriter holds a response in memory until the handler returns.
+// Flushing switches it to pass-through for streaming responses.
+type bufferedWriter struct {
+	http.ResponseWriter
+	buf         bytes.Buffer
+	status      int
+	wroteHeader bool
+	streaming   bool
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	if w.streaming {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.status = code
+	}
+}
+
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	if w.streaming {
+		return w.ResponseWriter.Write(b)
+	}
+	w.wroteHeader = true
+	return w.buf.Write(b)
+}
+
+// Flush sends the buffered response and streams the rest unmodified.
+func (w *bufferedWriter) Flush() {
+	if !w.streaming {
+		w.flushBuffered()
+		w.streaming = true
+	}
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController.
+func (w *bufferedWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// flushBuffered writes the buffered status and body.
+func (w *bufferedWriter) flushBuffered() {
+	w.ResponseWriter.WriteHeader(w.status)
+	w.ResponseWriter.Write(w.buf.Bytes())
+	w.buf.Reset()
+}
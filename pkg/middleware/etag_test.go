@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestETagConditionalGet(t *testing.T) {
+	h := ETag(ETagOptions{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "hello")
+	}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || etag == "" || w.Body.String() != "hello" {
+		t.Fatalf("status = %d, ETag = %q, body = %q", w.Code, etag, w.Body.String())
+	}
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Fatalf("status = %d, body = %q", w.Code, w.Body.String())
+	}
+}
+
+func TestETagResourcePreconditions(t *testing.T) {
+	tests := []struct {
+		name    string
+		current string
+		header  string
+		value   string
+		require bool
+		status  int
+	}{
+		{"unquoted tag matches", "v1", "If-Match", `"v1"`, false, http.StatusNoContent},
+		{"stale tag", "v1", "If-Match", `"v0"`, false, http.StatusPreconditionFailed},
+		{"if-match star on missing resource", "", "If-Match", "*", false, http.StatusPreconditionFailed},
+		{"if-none-match star creates", "", "If-None-Match", "*", false, http.StatusNoContent},
+		{"if-none-match star on existing", "v1", "If-None-Match", "*", false, http.StatusPreconditionFailed},
+		{"if-match required", "v1", "", "", true, http.StatusPreconditionRequired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := ETag(ETagOptions{
+				RequireIfMatch: tt.require,
+				ResourceETag:   func(r *http.Request) (string, error) { return tt.current, nil },
+			})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			r := httptest.NewRequest(http.MethodPut, "/", nil)
+			if tt.header != "" {
+				r.Header.Set(tt.header, tt.value)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,296 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/SailfinIO/sail/internal/server"
+)
+
+// DefaultIdempotencyHeader is the request header carrying the idempotency key.
+const DefaultIdempotencyHeader = "Idempotency-Key"
+
+// maxIdempotencyKey is the longest key accepted.
+const maxIdempotencyKey = 255
+
+// DefaultIdempotencyMaxBody is the default limit on request bodies read
+// by the Idempotency middleware.
+const DefaultIdempotencyMaxBody = 1 << 20
+
+// idempotencyStoreTimeout bounds the store calls that finish a request,
+// which run even if the client has gone away.
+const idempotencyStoreTimeout = 5 * time.Second
+
+// IdempotencyOptions configures the Idempotency middleware.
+type IdempotencyOptions struct {
+	// Header carries the key. Defaults to DefaultIdempotencyHeader.
+	Header string
+	// Methods the middleware applies to. Defaults to POST and PATCH.
+	Methods []string
+	// Required rejects requests without a key with 400 Bad Request.
+	Required bool
+	// TTL is how long responses are kept for replay. Defaults to 24 hours.
+	TTL time.Duration
+	// Store holds keys and responses. Defaults to a new MemoryIdempotencyStore.
+	Store IdempotencyStore
+	// MaxBodyBytes limits the request body read to fingerprint the request.
+	// Larger bodies are rejected with 413. Defaults to DefaultIdempotencyMaxBody.
+	MaxBodyBytes int64
+	// Scope namespaces keys per client so that one client cannot replay
+	// another's responses. It defaults to the authenticated principal's
+	// subject, then to a hash of the request's Authorization header or
+	// SessionCookie, since authentication often runs after this middleware,
+	// and finally to the client IP.
+	Scope func(r *http.Request) string
+	// SessionCookie names the cookie that identifies the client's session
+	// in the default Scope. Defaults to "sail_session", the Sessions default.
+	SessionCookie string
+	// TrustedProxies lists the reverse proxies whose X-Forwarded-For entries
+	// the default Scope uses to find the client IP.
+	TrustedProxies []string
+}
+
+// Idempotency returns a middleware that makes retried requests safe. The
+// first response for an Idempotency-Key is stored and replayed, with an
+// Idempotent-Replayed header, for later requests with the same key. A
+// repeat that arrives while the first request is still running gets
+// 409 Conflict, and reusing a key with a different request gets 422.
+// Server errors (5xx) are not stored so the client can retry.
+// It panics if TrustedProxies is invalid.
+func Idempotency(opts IdempotencyOptions) func(http.Handler) http.Handler {
+	if opts.Header == "" {
+		opts.Header = DefaultIdempotencyHeader
+	}
+	if len(opts.Methods) == 0 {
+		opts.Methods = []string{http.MethodPost, http.MethodPatch}
+	}
+	if opts.TTL <= 0 {
+		opts.TTL = 24 * time.Hour
+	}
+	if opts.Store == nil {
+		opts.Store = NewMemoryIdempotencyStore()
+	}
+	if opts.MaxBodyBytes <= 0 {
+		opts.MaxBodyBytes = DefaultIdempotencyMaxBody
+	}
+	if opts.SessionCookie == "" {
+		opts.SessionCookie = "sail_session"
+	}
+	if opts.Scope == nil {
+		opts.Scope = defaultIdempotencyScope(opts.SessionCookie, mustParseTrustedProxies(opts.TrustedProxies))
+	}
+	methods := make(map[string]bool, len(opts.Methods))
+	for _, m := range opts.Methods {
+		methods[m] = true
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !methods[r.Method] {
+				next.ServeHTTP(w, r)
+				return
+			}
+			key := r.Header.Get(opts.Header)
+			if key == "" {
+				if opts.Required {
+					server.WriteError(w, &server.HTTPError{
+						Status:  http.StatusBadRequest,
+						Code:    "idempotency_key_missing",
+						Message: "The " + opts.Header + " header is required",
+					})
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKey {
+				server.WriteError(w, &server.HTTPError{
+					Status:  http.StatusBadRequest,
+					Code:    "idempotency_key_invalid",
+					Message: "The " + opts.Header + " header is too long",
+				})
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, opts.MaxBodyBytes))
+			if err != nil {
+				server.WriteError(w, readError(err))
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			fingerprint := requestFingerprint(r, body)
+			storeKey := opts.Scope(r) + "|" + r.Method + "|" + r.URL.Path + "|" + key
+
+			ctx := r.Context()
+			rec, started, err := opts.Store.Begin(ctx, storeKey, fingerprint, opts.TTL)
+			if err != nil {
+				server.LoggerFromContext(ctx).Error("idempotency store: " + err.Error())
+				server.WriteError(w, err)
+				return
+			}
+			if !started {
+				switch {
+				case rec.Fingerprint != fingerprint:
+					server.WriteError(w, &server.HTTPError{
+						Status:  http.StatusUnprocessableEntity,
+						Code:    "idempotency_key_reused",
+						Message: "The " + opts.Header + " was already used for a different request",
+					})
+				case !rec.Completed:
+					w.Header().Set("Retry-After", "1")
+					server.WriteError(w, &server.HTTPError{
+						Status:  http.StatusConflict,
+						Code:    "idempotency_in_flight",
+						Message: "A request with this " + opts.Header + " is already being processed",
+					})
+				default:
+					replay(w, rec)
+				}
+				return
+			}
+
+			// Finish with a context that outlives the request, so that a
+			// client hanging up does not leave the key in flight.
+			finish := func(fn func(ctx context.Context) error) {
+				ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), idempotencyStoreTimeout)
+				defer cancel()
+				if err := fn(ctx); err != nil {
+					server.LoggerFromContext(ctx).Error("idempotency store: " + err.Error())
+				}
+			}
+			release := func(ctx context.Context) error { return opts.Store.Release(ctx, storeKey) }
+			cw := &captureWriter{responseWriter: newResponseWriter(w)}
+			completed := false
+			defer func() {
+				if !completed {
+					// Release on panic so the client can retry.
+					finish(release)
+				}
+			}()
+			next.ServeHTTP(cw, r)
+			completed = true
+			if cw.status >= 500 {
+				finish(release)
+				return
+			}
+			rec = &IdempotencyRecord{
+				Fingerprint: fingerprint,
+				Completed:   true,
+				Status:      cw.status,
+				Header:      cw.header,
+				Body:        cw.body.Bytes(),
+			}
+			if rec.Header == nil {
+				rec.Header = w.Header().Clone()
+			}
+			stripIdempotencyHeaders(rec.Header)
+			finish(func(ctx context.Context) error {
+				return opts.Store.Complete(ctx, storeKey, rec, opts.TTL)
+			})
+		})
+	}
+}
+
+// defaultIdempotencyScope identifies the client of a request, preferring the
+// authenticated principal, then its credentials or session, then its address.
+func defaultIdempotencyScope(sessionCookie string, proxies *TrustedProxies) func(r *http.Request) string {
+	return func(r *http.Request) string {
+		if p, ok := server.PrincipalFromContext(r.Context()); ok {
+			return "sub:" + p.Subject()
+		}
+		credentials := r.Header.Get("Authorization")
+		if credentials == "" {
+			if c, err := r.Cookie(sessionCookie); err == nil {
+				credentials = "session:" + c.Value
+			}
+		}
+		if credentials != "" {
+			sum := sha256.Sum256([]byte(credentials))
+			return "cred:" + hex.EncodeToString(sum[:])
+		}
+		return "ip:" + proxies.ClientIP(r)
+	}
+}
+
+// stripIdempotencyHeaders removes the response headers that describe one
+// exchange, such as the request ID and rate limit state, so that a replay
+// does not overwrite the current request's values.
+func stripIdempotencyHeaders(h http.Header) {
+	for k := range h {
+		if strings.HasPrefix(strings.ToLower(k), "ratelimit-") {
+			delete(h, k)
+		}
+	}
+	for _, k := range []string{"X-Request-Id", "Date"} {
+		h.Del(k)
+	}
+}
+
+// requestFingerprint hashes the parts of a request that must match on replay.
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// readError converts a request body read error to an HTTPError.
+func readError(err error) error {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		return payloadTooLarge(maxErr.Limit)
+	}
+	return &server.HTTPError{
+		Status:  http.StatusBadRequest,
+		Code:    "invalid_body",
+		Message: "Failed to read request body",
+	}
+}
+
+// replay writes a stored response.
+func replay(w http.ResponseWriter, rec *IdempotencyRecord) {
+	h := w.Header()
+	for k, v := range rec.Header {
+		h[k] = append([]string(nil), v...)
+	}
+	h.Set("Idempotent-Replayed", "true")
+	w.WriteHeader(rec.Status)
+	w.Write(rec.Body)
+}
+
+// captureWriter passes a response through while keeping a copy of its
+// status, headers and body.
+type captureWriter struct {
+	*responseWriter
+	header http.Header
+	body   bytes.Buffer
+}
+
+func (w *captureWriter) WriteHeader(code int) {
+	if !w.wroteHeader && (code < 100 || code > 199) {
+		w.header = w.ResponseWriter.Header().Clone()
+	}
+	w.responseWriter.WriteHeader(code)
+}
+
+func (w *captureWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	n, err := w.responseWriter.Write(b)
+	w.body.Write(b[:n])
+	return n, err
+}
+
+func (w *captureWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	w.responseWriter.Flush()
+}
@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// IdempotencyRecord is the stored state of one idempotency key.
+type IdempotencyRecord struct {
+	Fingerprint string // Hash of the request method, path and body.
+	Completed   bool   // False while the first request is still in flight.
+	Status      int
+	Header      http.Header
+	Body        []byte
+}
+
+// IdempotencyStore holds idempotency records. Implementations backed by a
+// shared service (e.g. Redis) let several instances share keys; Begin must
+// be atomic per key.
+type IdempotencyStore interface {
+	// Begin reserves key for a new request. If the key is already known it
+	// returns the existing record and started is false.
+	Begin(ctx context.Context, key, fingerprint string, ttl time.Duration) (rec *IdempotencyRecord, started bool, err error)
+	// Complete stores the response of a request reserved by Begin.
+	Complete(ctx context.Context, key string, rec *IdempotencyRecord, ttl time.Duration) error
+	// Release drops a reservation so the request can be retried.
+	Release(ctx context.Context, key string) error
+}
+
+// idempotencyEntry is a record with its expiry time.
+type idempotencyEntry struct {
+	rec     *IdempotencyRecord
+	expires time.Time
+}
+
+// MemoryIdempotencyStore is an in-process IdempotencyStore.
+// Expired keys are evicted once per minute.
+type MemoryIdempotencyStore struct {
+	mu        sync.Mutex
+	entries   map[string]idempotencyEntry
+	lastSweep time.Time
+}
+
+// NewMemoryIdempotencyStore creates an empty in-memory store.
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{entries: make(map[string]idempotencyEntry)}
+}
+
+// Begin implements IdempotencyStore.
+func (s *MemoryIdempotencyStore) Begin(_ context.Context, key, fingerprint string, ttl time.Duration) (*IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.sweep(now)
+	if e, ok := s.entries[key]; ok && now.Before(e.expires) {
+		return e.rec, false, nil
+	}
+	rec := &IdempotencyRecord{Fingerprint: fingerprint}
+	s.entries[key] = idempotencyEntry{rec: rec, expires: now.Add(ttl)}
+	return rec, true, nil
+}
+
+// Complete implements IdempotencyStore.
+func (s *MemoryIdempotencyStore) Complete(_ context.Context, key string, rec *IdempotencyRecord, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[key] = idempotencyEntry{rec: rec, expires: time.Now().Add(ttl)}
+	return nil
+}
+
+// Release implements IdempotencyStore.
+func (s *MemoryIdempotencyStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+	return nil
+}
+
+// Len returns the number of stored keys.
+func (s *MemoryIdempotencyStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+// sweep evicts expired keys at most once per minute.
+func (s *MemoryIdempotencyStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+	for key, e := range s.entries {
+		if now.After(e.expires) {
+			delete(s.entries, key)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIdempotency(t *testing.T) {
+	type step struct {
+		key      string
+		body     string
+		remote   string
+		auth     string
+		cookie   string
+		status   int
+		replayed bool
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{"replay", []step{
+			{key: "k1", body: "a", status: http.StatusCreated},
+			{key: "k1", body: "a", status: http.StatusCreated, replayed: true},
+		}},
+		{"reused for a different body", []step{
+			{key: "k1", body: "a", status: http.StatusCreated},
+			{key: "k1", body: "b", status: http.StatusUnprocessableEntity},
+		}},
+		{"anonymous clients are isolated by address", []step{
+			{key: "k1", body: "a", remote: "192.0.2.1:1", status: http.StatusCreated},
+			{key: "k1", body: "a", remote: "192.0.2.2:1", status: http.StatusCreated},
+		}},
+		{"clients are isolated by credentials", []step{
+			{key: "k1", body: "a", auth: "Bearer alice", status: http.StatusCreated},
+			{key: "k1", body: "a", auth: "Bearer bob", status: http.StatusCreated},
+			{key: "k1", body: "a", auth: "Bearer alice", status: http.StatusCreated, replayed: true},
+		}},
+		{"clients are isolated by session cookie", []step{
+			{key: "k1", body: "a", cookie: "sail_session=s1; theme=dark", status: http.StatusCreated},
+			{key: "k1", body: "a", cookie: "sail_session=s2", status: http.StatusCreated},
+			{key: "k1", body: "a", cookie: "sail_session=s1; theme=light", status: http.StatusCreated, replayed: true},
+		}},
+		{"body over limit", []step{
+			{key: "k1", body: strings.Repeat("x", 64), status: http.StatusRequestEntityTooLarge},
+		}},
+		{"no key", []step{
+			{body: "a", status: http.StatusCreated},
+			{body: "a", status: http.StatusCreated},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			h := Idempotency(IdempotencyOptions{MaxBodyBytes: 32})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				b, _ := io.ReadAll(r.Body)
+				w.WriteHeader(http.StatusCreated)
+				fmt.Fprintf(w, "%s-%d", b, calls)
+			}))
+			bodies := map[string]string{}
+			for i, s := range tt.steps {
+				r := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(s.body))
+				if s.key != "" {
+					r.Header.Set(DefaultIdempotencyHeader, s.key)
+				}
+				if s.remote != "" {
+					r.RemoteAddr = s.remote
+				}
+				if s.auth != "" {
+					r.Header.Set("Authorization", s.auth)
+				}
+				if s.cookie != "" {
+					r.Header.Set("Cookie", s.cookie)
+				}
+				w := httptest.NewRecorder()
+				h.ServeHTTP(w, r)
+				if w.Code != s.status {
+					t.Fatalf("step %d: status = %d, want %d (%s)", i, w.Code, s.status, w.Body.String())
+				}
+				replayed := w.Header().Get("Idempotent-Replayed") == "true"
+				if replayed != s.replayed {
+					t.Fatalf("step %d: replayed = %v, want %v", i, replayed, s.replayed)
+				}
+				scope := s.auth + s.remote + strings.Split(s.cookie, ";")[0]
+				if replayed && w.Body.String() != bodies[scope] {
+					t.Fatalf("step %d: replayed body %q, want %q", i, w.Body.String(), bodies[scope])
+				}
+				if !replayed && w.Code == http.StatusCreated {
+					bodies[scope] = w.Body.String()
+				}
+			}
+		})
+	}
+}
+
+func TestIdempotencyServerErrorsAreNotStored(t *testing.T) {
+	calls := 0
+	h := Idempotency(IdempotencyOptions{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	for i := 0; i < 2; i++ {
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		r.Header.Set(DefaultIdempotencyHeader, "k")
+		h.ServeHTTP(httptest.NewRecorder(), r)
+	}
+	if calls != 2 {
+		t.Fatalf("handler ran %d times, want 2", calls)
+	}
+}
+
+// ctxCheckingStore fails calls made with a cancelled context.
+type ctxCheckingStore struct {
+	*MemoryIdempotencyStore
+}
+
+func (s ctxCheckingStore) Complete(ctx context.Context, key string, rec *IdempotencyRecord, ttl time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.MemoryIdempotencyStore.Complete(ctx, key, rec, ttl)
+}
+
+func TestIdempotencyCompletesAfterClientLeaves(t *testing.T) {
+	store := ctxCheckingStore{NewMemoryIdempotencyStore()}
+	h := Idempotency(IdempotencyOptions{Store: store})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "first")
+		w.Header().Set("RateLimit-Remaining", "9")
+		w.Header().Set("Location", "/orders/1")
+		w.WriteHeader(http.StatusCreated)
+	}))
+	ctx, cancel := context.WithCancel(context.Background())
+	r := httptest.NewRequest(http.MethodPost, "/orders", nil).WithContext(ctx)
+	r.Header.Set(DefaultIdempotencyHeader, "k")
+	h.ServeHTTP(&cancelOnWrite{httptest.NewRecorder(), cancel}, r)
+
+	r = httptest.NewRequest(http.MethodPost, "/orders", nil)
+	r.Header.Set(DefaultIdempotencyHeader, "k")
+	w := httptest.NewRecorder()
+	w.Header().Set("X-Request-Id", "second")
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusCreated || w.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("status = %d, replayed = %q", w.Code, w.Header().Get("Idempotent-Replayed"))
+	}
+	if got := w.Header().Get("X-Request-Id"); got != "second" {
+		t.Fatalf("X-Request-Id = %q, want the replaying request's", got)
+	}
+	if w.Header().Get("RateLimit-Remaining") != "" || w.Header().Get("Location") != "/orders/1" {
+		t.Fatalf("replayed header = %v", w.Header())
+	}
+}
+
+// cancelOnWrite cancels the request context once the response starts, as
+// when the client disconnects.
+type cancelOnWrite struct {
+	*httptest.ResponseRecorder
+	cancel context.CancelFunc
+}
+
+func (w *cancelOnWrite) WriteHeader(code int) {
+	w.ResponseRecorder.WriteHeader(code)
+	w.cancel()
+}
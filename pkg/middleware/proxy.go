@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// TrustedProxies is the set of reverse proxies whose X-Forwarded-For
+// entries are believed. A nil *TrustedProxies trusts no proxy.
+type TrustedProxies struct {
+	prefixes []netip.Prefix
+}
+
+// ParseTrustedProxies parses proxy addresses and CIDR ranges, e.g.
+// "10.0.0.0/8" or "192.0.2.1".
+func ParseTrustedProxies(addrs ...string) (*TrustedProxies, error) {
+	t := &TrustedProxies{}
+	for _, a := range addrs {
+		a = strings.TrimSpace(a)
+		if strings.Contains(a, "/") {
+			p, err := netip.ParsePrefix(a)
+			if err != nil {
+				return nil, fmt.Errorf("middleware: invalid trusted proxy %q: %w", a, err)
+			}
+			t.prefixes = append(t.prefixes, p.Masked())
+			continue
+		}
+		ip, err := netip.ParseAddr(a)
+		if err != nil {
+			return nil, fmt.Errorf("middleware: invalid trusted proxy %q: %w", a, err)
+		}
+		t.prefixes = append(t.prefixes, netip.PrefixFrom(ip, ip.BitLen()))
+	}
+	return t, nil
+}
+
+// mustParseTrustedProxies is ParseTrustedProxies for middleware that
+// panics on misconfiguration. It returns nil for an empty list.
+func mustParseTrustedProxies(addrs []string) *TrustedProxies {
+	if len(addrs) == 0 {
+		return nil
+	}
+	t, err := ParseTrustedProxies(addrs...)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// contains reports whether addr belongs to a trusted proxy.
+func (t *TrustedProxies) contains(addr string) bool {
+	if t == nil {
+		return false
+	}
+	ip, err := netip.ParseAddr(addr)
+	if err != nil {
+		return false
+	}
+	ip = ip.Unmap()
+	for _, p := range t.prefixes {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the address of the client that sent r. X-Forwarded-For
+// is only consulted when the connection comes from a trusted proxy; the
+// rightmost entry that is not itself a trusted proxy is used, since
+// entries to its left are supplied by the client and can be forged.
+func (t *TrustedProxies) ClientIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	if !t.contains(ip) {
+		return ip
+	}
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+		if !t.contains(hop) {
+			return hop
+		}
+		ip = hop
+	}
+	return ip
+}
@@ -0,0 +1,145 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SailfinIO/sail/internal/server"
+)
+
+// RateLimitKeyFunc derives the key a request is counted under.
+// Returning an empty string exempts the request from the limit.
+type RateLimitKeyFunc func(r *http.Request) string
+
+// KeyByIP counts requests per client IP, as determined by
+// TrustedProxies.ClientIP. A nil proxies uses the connection's address.
+func KeyByIP(proxies *TrustedProxies) RateLimitKeyFunc {
+	return func(r *http.Request) string {
+		return "ip:" + proxies.ClientIP(r)
+	}
+}
+
+// KeyByHeader counts requests per value of a header, e.g. an API key.
+// Requests without the header are not limited.
+func KeyByHeader(name string) RateLimitKeyFunc {
+	return func(r *http.Request) string {
+		if v := r.Header.Get(name); v != "" {
+			return "header:" + v
+		}
+		return ""
+	}
+}
+
+// KeyByRoute counts requests per matched route pattern, across all clients.
+func KeyByRoute() RateLimitKeyFunc {
+	return func(r *http.Request) string {
+		if route, ok := server.RouteFromContext(r.Context()); ok {
+			return "route:" + route.Pattern
+		}
+		return "route:" + r.URL.Path
+	}
+}
+
+// KeyByUser counts requests per authenticated principal, falling back to
+// the client IP, as determined by proxies, for anonymous requests.
+func KeyByUser(proxies *TrustedProxies) RateLimitKeyFunc {
+	return func(r *http.Request) string {
+		if p, ok := server.PrincipalFromContext(r.Context()); ok {
+			return "user:" + p.Subject()
+		}
+		return "ip:" + proxies.ClientIP(r)
+	}
+}
+
+// CombineKeys joins several keys, e.g. per user and route.
+func CombineKeys(fns ...RateLimitKeyFunc) RateLimitKeyFunc {
+	return func(r *http.Request) string {
+		parts := make([]string, len(fns))
+		for i, fn := range fns {
+			if parts[i] = fn(r); parts[i] == "" {
+				return ""
+			}
+		}
+		return strings.Join(parts, "|")
+	}
+}
+
+// RateLimitOptions configures the RateLimit middleware.
+type RateLimitOptions struct {
+	// Algorithm selects TokenBucket (default) or SlidingWindow.
+	Algorithm RateLimitAlgorithm
+	// Requests is the number of requests allowed per Window.
+	Requests int
+	// Window is the period over which Requests are counted.
+	Window time.Duration
+	// Key derives the counter key. Defaults to KeyByIP with TrustedProxies.
+	Key RateLimitKeyFunc
+	// TrustedProxies lists the addresses and CIDR ranges of reverse proxies
+	// whose X-Forwarded-For entries the default key uses.
+	TrustedProxies []string
+	// Store records the counters. Defaults to a new MemoryRateLimitStore.
+	Store RateLimitStore
+	// Name namespaces the keys, so limiters sharing a store do not collide.
+	Name string
+	// DisableHeaders omits the RateLimit-* response headers.
+	DisableHeaders bool
+}
+
+// RateLimit returns a middleware that rejects requests over the configured
+// limit with 429 Too Many Requests. Apply it with App.Use, to a route group
+// or to a single route. If the store fails, the request is allowed and the
+// error logged. It panics if Requests or Window is not positive or if
+// TrustedProxies is invalid.
+func RateLimit(opts RateLimitOptions) func(http.Handler) http.Handler {
+	if opts.Requests <= 0 || opts.Window <= 0 {
+		panic("middleware: RateLimit requires positive Requests and Window")
+	}
+	key := opts.Key
+	if key == nil {
+		key = KeyByIP(mustParseTrustedProxies(opts.TrustedProxies))
+	}
+	store := opts.Store
+	if store == nil {
+		store = NewMemoryRateLimitStore()
+	}
+	policy := RateLimitPolicy{Algorithm: opts.Algorithm, Requests: opts.Requests, Window: opts.Window}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			k := key(r)
+			if k == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			res, err := store.Take(r.Context(), opts.Name+":"+k, policy)
+			if err != nil {
+				server.LoggerFromContext(r.Context()).Error("Rate limit store error: " + err.Error())
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !opts.DisableHeaders {
+				h := w.Header()
+				h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+				h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+				h.Set("RateLimit-Reset", ceilSeconds(res.Reset))
+			}
+			if !res.Allowed {
+				w.Header().Set("Retry-After", ceilSeconds(res.RetryAfter))
+				server.WriteError(w, &server.HTTPError{
+					Status:  http.StatusTooManyRequests,
+					Code:    "rate_limited",
+					Message: "Too many requests",
+				})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ceilSeconds formats a duration as whole seconds, rounding up.
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,174 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// RateLimitAlgorithm selects how requests are counted.
+type RateLimitAlgorithm int
+
+const (
+	// TokenBucket allows bursts up to the limit and refills evenly over the window.
+	TokenBucket RateLimitAlgorithm = iota
+	// SlidingWindow approximates a rolling window by weighting the previous
+	// fixed window's count.
+	SlidingWindow
+)
+
+// RateLimitPolicy describes a limit of Requests per Window.
+type RateLimitPolicy struct {
+	Algorithm RateLimitAlgorithm
+	Requests  int
+	Window    time.Duration
+}
+
+// RateLimitResult is the outcome of counting one request.
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration // Time until the limit is fully restored.
+	RetryAfter time.Duration // Time until the next request is allowed, when denied.
+}
+
+// RateLimitStore records requests per key. Implementations backed by a
+// shared service (e.g. Redis) let several instances enforce one limit;
+// they must apply the policy atomically per key.
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, policy RateLimitPolicy) (RateLimitResult, error)
+}
+
+// rateLimitEntry holds the state of one key.
+type rateLimitEntry struct {
+	// Token bucket state.
+	tokens float64
+	last   time.Time
+	// Sliding window state.
+	windowStart time.Time
+	current     int
+	previous    int
+
+	expires time.Time
+}
+
+// MemoryRateLimitStore is an in-process RateLimitStore.
+// Idle keys are evicted once their window has passed.
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	entries   map[string]*rateLimitEntry
+	lastSweep time.Time
+	// SweepInterval is how often expired keys are evicted. Defaults to one minute.
+	SweepInterval time.Duration
+	now           func() time.Time
+}
+
+// NewMemoryRateLimitStore creates an empty in-memory store.
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		entries:       make(map[string]*rateLimitEntry),
+		SweepInterval: time.Minute,
+		now:           time.Now,
+	}
+}
+
+// Take implements RateLimitStore.
+func (s *MemoryRateLimitStore) Take(_ context.Context, key string, policy RateLimitPolicy) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.sweep(now)
+	e, ok := s.entries[key]
+	if !ok {
+		e = &rateLimitEntry{tokens: float64(policy.Requests), last: now, windowStart: now}
+		s.entries[key] = e
+	}
+	var res RateLimitResult
+	if policy.Algorithm == SlidingWindow {
+		res = e.takeWindow(now, policy)
+	} else {
+		res = e.takeToken(now, policy)
+	}
+	e.expires = now.Add(2 * policy.Window)
+	return res, nil
+}
+
+// Len returns the number of tracked keys.
+func (s *MemoryRateLimitStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+// sweep evicts expired keys at most once per SweepInterval.
+func (s *MemoryRateLimitStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < s.SweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, e := range s.entries {
+		if now.After(e.expires) {
+			delete(s.entries, key)
+		}
+	}
+}
+
+// takeToken applies the token bucket algorithm.
+func (e *rateLimitEntry) takeToken(now time.Time, p RateLimitPolicy) RateLimitResult {
+	capacity := float64(p.Requests)
+	rate := capacity / p.Window.Seconds() // Tokens per second.
+	e.tokens = math.Min(capacity, e.tokens+now.Sub(e.last).Seconds()*rate)
+	e.last = now
+	res := RateLimitResult{Limit: p.Requests}
+	if e.tokens >= 1 {
+		e.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = seconds((1 - e.tokens) / rate)
+	}
+	res.Remaining = int(e.tokens)
+	res.Reset = seconds((capacity - e.tokens) / rate)
+	return res
+}
+
+// takeWindow applies the sliding window counter algorithm.
+func (e *rateLimitEntry) takeWindow(now time.Time, p RateLimitPolicy) RateLimitResult {
+	elapsed := now.Sub(e.windowStart)
+	if elapsed >= p.Window {
+		windows := int(elapsed / p.Window)
+		if windows == 1 {
+			e.previous = e.current
+		} else {
+			e.previous = 0
+		}
+		e.current = 0
+		e.windowStart = e.windowStart.Add(time.Duration(windows) * p.Window)
+		elapsed = now.Sub(e.windowStart)
+	}
+	weight := 1 - float64(elapsed)/float64(p.Window)
+	estimate := float64(e.previous)*weight + float64(e.current)
+	res := RateLimitResult{Limit: p.Requests, Reset: p.Window - elapsed}
+	if estimate+1 <= float64(p.Requests) {
+		e.current++
+		estimate++
+		res.Allowed = true
+	} else {
+		res.RetryAfter = p.Window - elapsed
+		if free := float64(p.Requests - e.current - 1); e.previous > 0 && free >= 0 {
+			// Wait until the previous window's weight has decayed enough.
+			wait := time.Duration(float64(p.Window)*(1-free/float64(e.previous))) - elapsed
+			if wait < res.RetryAfter {
+				res.RetryAfter = wait
+			}
+		}
+	}
+	res.Remaining = int(math.Max(0, float64(p.Requests)-math.Ceil(estimate)))
+	return res
+}
+
+// seconds converts fractional seconds to a Duration.
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/SailfinIO/sail/internal/server"
+)
+
+func TestRateLimitOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts RateLimitOptions
+		ok   bool
+	}{
+		{"valid", RateLimitOptions{Requests: 1, Window: time.Minute}, true},
+		{"zero requests", RateLimitOptions{Window: time.Minute}, false},
+		{"zero window", RateLimitOptions{Requests: 1}, false},
+		{"invalid proxy", RateLimitOptions{Requests: 1, Window: time.Minute, TrustedProxies: []string{"10.0.0.0/99"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if v := recover(); (v == nil) != tt.ok {
+					t.Fatalf("RateLimit panic = %v, want ok %v", v, tt.ok)
+				}
+			}()
+			RateLimit(tt.opts)
+		})
+	}
+}
+
+func TestRateLimitForwardedFor(t *testing.T) {
+	tests := []struct {
+		name    string
+		proxies []string
+		remote  func(i int) string
+		spoof   bool
+		allowed int
+	}{
+		{"spoofed header without trusted proxies", nil, func(int) string { return "203.0.113.5:1" }, true, 2},
+		{"spoofed leftmost entry behind a trusted proxy", []string{"10.0.0.0/8"}, func(int) string { return "10.0.0.1:1" }, true, 2},
+		{"distinct clients", nil, func(i int) string { return "203.0.113." + strconv.Itoa(i) + ":1" }, false, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := RateLimit(RateLimitOptions{Requests: 2, Window: time.Minute, TrustedProxies: tt.proxies})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			allowed := 0
+			for i := 0; i < 5; i++ {
+				r := httptest.NewRequest(http.MethodGet, "/", nil)
+				r.RemoteAddr = tt.remote(i)
+				if tt.spoof {
+					r.Header.Set("X-Forwarded-For", "198.51.100."+strconv.Itoa(i)+", 198.51.100.200")
+				}
+				w := httptest.NewRecorder()
+				h.ServeHTTP(w, r)
+				if w.Code == http.StatusOK {
+					allowed++
+				} else if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+					t.Fatalf("status = %d, Retry-After = %q", w.Code, w.Header().Get("Retry-After"))
+				}
+			}
+			if allowed != tt.allowed {
+				t.Fatalf("allowed %d requests, want %d", allowed, tt.allowed)
+			}
+		})
+	}
+}
+
+func TestMemoryRateLimitStoreAlgorithms(t *testing.T) {
+	for _, alg := range []RateLimitAlgorithm{TokenBucket, SlidingWindow} {
+		now := time.Unix(1000, 0)
+		s := NewMemoryRateLimitStore()
+		s.now = func() time.Time { return now }
+		policy := RateLimitPolicy{Algorithm: alg, Requests: 3, Window: time.Second}
+		for i := 0; i < 3; i++ {
+			if res, _ := s.Take(context.Background(), "k", policy); !res.Allowed {
+				t.Fatalf("algorithm %d: request %d denied", alg, i)
+			}
+		}
+		if res, _ := s.Take(context.Background(), "k", policy); res.Allowed || res.RetryAfter <= 0 {
+			t.Fatalf("algorithm %d: fourth request = %+v", alg, res)
+		}
+		now = now.Add(2 * time.Second)
+		if res, _ := s.Take(context.Background(), "k", policy); !res.Allowed {
+			t.Fatalf("algorithm %d: request after window denied", alg)
+		}
+	}
+}
+
+func TestKeyByUser(t *testing.T) {
+	proxies, err := ParseTrustedProxies("10.0.0.0/8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := KeyByUser(proxies)
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "10.0.0.1:1"
+	r.Header.Set("X-Forwarded-For", "198.51.100.7")
+	if got := key(r); got != "ip:198.51.100.7" {
+		t.Fatalf("anonymous key = %q", got)
+	}
+	r = r.WithContext(server.WithPrincipal(r.Context(), testPrincipal("bob")))
+	if got := key(r); got != "user:bob" {
+		t.Fatalf("user key = %q", got)
+	}
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/SailfinIO/sail/internal/logger"
+	"github.com/SailfinIO/sail/internal/server"
+)
+
+// PanicReporter receives panics recovered by the Recovery middleware,
+// e.g. to forward them to an error tracking service.
+type PanicReporter interface {
+	ReportPanic(r *http.Request, value interface{}, stack []byte)
+}
+
+// PanicReporterFunc adapts an ordinary function to the PanicReporter interface.
+type PanicReporterFunc func(r *http.Request, value interface{}, stack []byte)
+
+// ReportPanic calls f(r, value, stack).
+func (f PanicReporterFunc) ReportPanic(r *http.Request, value interface{}, stack []byte) {
+	f(r, value, stack)
+}
+
+// RecoveryOptions configures the Recovery middleware.
+type RecoveryOptions struct {
+	// Logger receives the panic and stack trace. Defaults to the request logger.
+	Logger logger.Logger
+	// Reporter, if set, is called for every recovered panic.
+	Reporter PanicReporter
+	// DisableStack omits the stack trace from the log.
+	DisableStack bool
+}
+
+// Recovery returns a middleware that recovers from panics in later handlers,
+// logs them and replies with a 500 in the framework's error format.
+// Panics with http.ErrAbortHandler, and panics after the response has
+// started, are raised as http.ErrAbortHandler so the server aborts the
+// connection.
+func Recovery(opts RecoveryOptions) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rw := newResponseWriter(w)
+			defer func() {
+				v := recover()
+				if v == nil {
+					return
+				}
+				if v == http.ErrAbortHandler {
+					panic(v)
+				}
+				stack := debug.Stack()
+				logg := server.LoggerFromContext(r.Context())
+				if opts.Logger != nil {
+					logg = opts.Logger
+					if id := server.RequestIDFromContext(r.Context()); id != "" {
+						logg = logger.WithField(logg, "request_id", id)
+					}
+				}
+				msg := fmt.Sprintf("Panic recovered: %v [%s %s]", v, r.Method, r.URL.Path)
+				if !opts.DisableStack {
+					msg += "\n" + string(stack)
+				}
+				logg.Error(msg)
+				if opts.Reporter != nil {
+					opts.Reporter.ReportPanic(r, v, stack)
+				}
+				if rw.wroteHeader {
+					// The response has started. Abort the connection so the
+					// client does not mistake the truncated body for a
+					// complete one.
+					panic(http.ErrAbortHandler)
+				}
+				server.WriteError(rw, server.NewHTTPError(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError)))
+			}()
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
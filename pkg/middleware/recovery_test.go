@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRecovery(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		status  int
+		abort   bool // The panic propagates as http.ErrAbortHandler.
+	}{
+		{"panic before writing", func(w http.ResponseWriter, r *http.Request) {
+			panic("boom")
+		}, http.StatusInternalServerError, false},
+		{"panic after writing", func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, "partial")
+			panic("boom")
+		}, http.StatusOK, true},
+		{"abort handler", func(w http.ResponseWriter, r *http.Request) {
+			panic(http.ErrAbortHandler)
+		}, http.StatusOK, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reported := 0
+			h := Recovery(RecoveryOptions{DisableStack: true, Reporter: PanicReporterFunc(func(*http.Request, interface{}, []byte) {
+				reported++
+			})})(tt.handler)
+			w := httptest.NewRecorder()
+			func() {
+				defer func() {
+					v := recover()
+					if aborted := v == http.ErrAbortHandler; aborted != tt.abort || (v != nil && !aborted) {
+						t.Fatalf("recovered %v, want abort %v", v, tt.abort)
+					}
+				}()
+				h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			}()
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			if want := tt.name != "abort handler"; (reported == 1) != want {
+				t.Fatalf("reported %d panics", reported)
+			}
+		})
+	}
+}
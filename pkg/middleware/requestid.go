@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/http"
+
+	"github.com/SailfinIO/sail/internal/server"
+)
+
+// DefaultRequestIDHeader is the header used to carry request IDs.
+const DefaultRequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds incoming request IDs accepted from clients.
+const maxRequestIDLength = 128
+
+// RequestIDOptions configures the RequestID middleware.
+type RequestIDOptions struct {
+	// Header carries the ID on requests and responses. Defaults to X-Request-ID.
+	Header string
+	// Generator creates IDs for requests without one. Defaults to a random UUID.
+	Generator func() string
+	// IgnoreIncoming always generates a new ID instead of trusting the client's.
+	IgnoreIncoming bool
+}
+
+// RequestID returns a middleware that reads the request ID from the incoming
+// header or generates one, stores it in the request context and echoes it in
+// the response. The incoming request headers are left untouched; read the ID
+// with sail.RequestIDFromContext. Loggers obtained from the request context include it.
+func RequestID(opts RequestIDOptions) func(http.Handler) http.Handler {
+	header := opts.Header
+	if header == "" {
+		header = DefaultRequestIDHeader
+	}
+	generate := opts.Generator
+	if generate == nil {
+		generate = newUUID
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := ""
+			if !opts.IgnoreIncoming {
+				id = r.Header.Get(header)
+			}
+			if !validRequestID(id) {
+				id = generate()
+			}
+			w.Header().Set(header, id)
+			next.ServeHTTP(w, r.WithContext(server.WithRequestID(r.Context(), id)))
+		})
+	}
+}
+
+// validRequestID reports whether an incoming ID is safe to log and echo.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// newUUID returns a random (version 4) UUID.
+func newUUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/SailfinIO/sail/internal/server"
+)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+		opts     RequestIDOptions
+		reuse    bool
+	}{
+		{"generated", "", RequestIDOptions{}, false},
+		{"incoming", "abc-123", RequestIDOptions{}, true},
+		{"invalid incoming", "bad id\n", RequestIDOptions{}, false},
+		{"too long", strings.Repeat("a", maxRequestIDLength+1), RequestIDOptions{}, false},
+		{"ignore incoming", "abc-123", RequestIDOptions{IgnoreIncoming: true}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ctxID, headerID string
+			h := RequestID(tt.opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ctxID = server.RequestIDFromContext(r.Context())
+				headerID = r.Header.Get(DefaultRequestIDHeader)
+			}))
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.incoming != "" {
+				r.Header.Set(DefaultRequestIDHeader, tt.incoming)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if ctxID == "" || w.Header().Get(DefaultRequestIDHeader) != ctxID {
+				t.Fatalf("context ID %q, response header %q", ctxID, w.Header().Get(DefaultRequestIDHeader))
+			}
+			if tt.reuse != (ctxID == tt.incoming) {
+				t.Fatalf("ID = %q, incoming %q, reuse %v", ctxID, tt.incoming, tt.reuse)
+			}
+			if headerID != tt.incoming || r.Header.Get(DefaultRequestIDHeader) != tt.incoming {
+				t.Fatalf("request header modified: %q", headerID)
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SailfinIO/sail/internal/server"
+)
+
+// HSTSOptions configures the Strict-Transport-Security header.
+type HSTSOptions struct {
+	// MaxAge is how long browsers remember to use HTTPS. Zero omits the header.
+	MaxAge            time.Duration
+	IncludeSubDomains bool
+	Preload           bool
+}
+
+// CSPOptions configures the Content-Security-Policy header.
+type CSPOptions struct {
+	// Directives maps directive names to their sources, e.g.
+	// "script-src": {"'self'"}. A directive with no sources, such as
+	// "upgrade-insecure-requests", is written on its own. Nil omits the header.
+	Directives map[string][]string
+	// ReportOnly sends Content-Security-Policy-Report-Only instead.
+	ReportOnly bool
+	// Nonce generates a nonce per request and adds it to NonceDirectives.
+	// Read it with CSPNonce or sail.Context.CSPNonce.
+	Nonce bool
+	// NonceDirectives receive the nonce. Defaults to script-src and style-src.
+	// Directives allowing 'unsafe-inline' are left without a nonce, since
+	// browsers ignore 'unsafe-inline' once a nonce is present.
+	NonceDirectives []string
+}
+
+// FrameOption is a value of the X-Frame-Options header.
+type FrameOption string
+
+const (
+	FrameDeny       FrameOption = "DENY"
+	FrameSameOrigin FrameOption = "SAMEORIGIN"
+)
+
+// SecurityHeadersOptions configures the SecurityHeaders middleware.
+// Empty fields omit their header; start from DefaultSecurityHeadersOptions.
+type SecurityHeadersOptions struct {
+	HSTS                         HSTSOptions
+	ContentSecurityPolicy        CSPOptions
+	FrameOptions                 FrameOption
+	ReferrerPolicy               string // e.g. "no-referrer", "strict-origin-when-cross-origin".
+	ContentTypeNosniff           bool
+	CrossOriginOpenerPolicy      string // e.g. "same-origin".
+	CrossOriginResourcePolicy    string // e.g. "same-origin".
+	CrossOriginEmbedderPolicy    string // e.g. "require-corp".
+	OriginAgentCluster           bool
+	PermissionsPolicy            string // e.g. "camera=(), geolocation=()".
+	DNSPrefetchControl           string // "off" or "on".
+	PermittedCrossDomainPolicies string // e.g. "none".
+	DownloadOptions              bool   // Sends X-Download-Options: noopen.
+	// XSSProtection is sent as X-XSS-Protection. "0" disables the legacy
+	// browser filter, which is the recommended setting.
+	XSSProtection string
+}
+
+// DefaultSecurityHeadersOptions returns defaults matching helmet.
+func DefaultSecurityHeadersOptions() SecurityHeadersOptions {
+	return SecurityHeadersOptions{
+		HSTS: HSTSOptions{MaxAge: 365 * 24 * time.Hour, IncludeSubDomains: true},
+		ContentSecurityPolicy: CSPOptions{Directives: map[string][]string{
+			"default-src":               {"'self'"},
+			"base-uri":                  {"'self'"},
+			"font-src":                  {"'self'", "https:", "data:"},
+			"form-action":               {"'self'"},
+			"frame-ancestors":           {"'self'"},
+			"img-src":                   {"'self'", "data:"},
+			"object-src":                {"'none'"},
+			"script-src":                {"'self'"},
+			"script-src-attr":           {"'none'"},
+			"style-src":                 {"'self'", "https:", "'unsafe-inline'"},
+			"upgrade-insecure-requests": nil,
+		}},
+		FrameOptions:                 FrameSameOrigin,
+		ReferrerPolicy:               "no-referrer",
+		ContentTypeNosniff:           true,
+		CrossOriginOpenerPolicy:      "same-origin",
+		CrossOriginResourcePolicy:    "same-origin",
+		OriginAgentCluster:           true,
+		DNSPrefetchControl:           "off",
+		PermittedCrossDomainPolicies: "none",
+		DownloadOptions:              true,
+		XSSProtection:                "0",
+	}
+}
+
+// SecurityHeaders returns a middleware that sets the configured security headers.
+func SecurityHeaders(opts SecurityHeadersOptions) func(http.Handler) http.Handler {
+	static := make(http.Header)
+	set := func(key, value string) {
+		if value != "" {
+			static.Set(key, value)
+		}
+	}
+	if opts.HSTS.MaxAge > 0 {
+		hsts := "max-age=" + strconv.FormatInt(int64(opts.HSTS.MaxAge/time.Second), 10)
+		if opts.HSTS.IncludeSubDomains {
+			hsts += "; includeSubDomains"
+		}
+		if opts.HSTS.Preload {
+			hsts += "; preload"
+		}
+		set("Strict-Transport-Security", hsts)
+	}
+	set("X-Frame-Options", string(opts.FrameOptions))
+	set("Referrer-Policy", opts.ReferrerPolicy)
+	if opts.ContentTypeNosniff {
+		set("X-Content-Type-Options", "nosniff")
+	}
+	set("Cross-Origin-Opener-Policy", opts.CrossOriginOpenerPolicy)
+	set("Cross-Origin-Resource-Policy", opts.CrossOriginResourcePolicy)
+	set("Cross-Origin-Embedder-Policy", opts.CrossOriginEmbedderPolicy)
+	if opts.OriginAgentCluster {
+		set("Origin-Agent-Cluster", "?1")
+	}
+	set("Permissions-Policy", opts.PermissionsPolicy)
+	set("X-DNS-Prefetch-Control", opts.DNSPrefetchControl)
+	set("X-Permitted-Cross-Domain-Policies", opts.PermittedCrossDomainPolicies)
+	if opts.DownloadOptions {
+		set("X-Download-Options", "noopen")
+	}
+	set("X-XSS-Protection", opts.XSSProtection)
+
+	csp := opts.ContentSecurityPolicy
+	cspHeader := "Content-Security-Policy"
+	if csp.ReportOnly {
+		cspHeader = "Content-Security-Policy-Report-Only"
+	}
+	nonceDirectives := csp.NonceDirectives
+	if nonceDirectives == nil {
+		nonceDirectives = []string{"script-src", "style-src"}
+	}
+	if csp.Directives != nil && !csp.Nonce {
+		set(cspHeader, buildCSP(csp.Directives, "", nil))
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			for k, v := range static {
+				h.Set(k, v[0])
+			}
+			if csp.Directives != nil && csp.Nonce {
+				nonce := newNonce()
+				h.Set(cspHeader, buildCSP(csp.Directives, nonce, nonceDirectives))
+				r = r.WithContext(server.WithCSPNonce(r.Context(), nonce))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// CSPNonce returns the Content-Security-Policy nonce generated for r, or an
+// empty string when nonces are disabled.
+func CSPNonce(r *http.Request) string {
+	return server.CSPNonceFromContext(r.Context())
+}
+
+// buildCSP renders directives in name order, adding the nonce source to
+// nonceDirectives when a nonce is given.
+func buildCSP(directives map[string][]string, nonce string, nonceDirectives []string) string {
+	withNonce := make(map[string]bool, len(nonceDirectives))
+	if nonce != "" {
+		for _, d := range nonceDirectives {
+			withNonce[d] = true
+			if _, ok := directives[d]; !ok {
+				// Fall back to default-src so adding a nonce does not relax the policy.
+				directives = copyDirectives(directives)
+				directives[d] = directives["default-src"]
+			}
+		}
+	}
+	names := make([]string, 0, len(directives))
+	for name := range directives {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		sources := directives[name]
+		if withNonce[name] && !containsFold(sources, "'unsafe-inline'") {
+			sources = append(append([]string(nil), sources...), "'nonce-"+nonce+"'")
+		}
+		if len(sources) == 0 {
+			parts = append(parts, name)
+			continue
+		}
+		parts = append(parts, name+" "+strings.Join(sources, " "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// containsFold reports whether sources includes source, ignoring case.
+func containsFold(sources []string, source string) bool {
+	for _, s := range sources {
+		if strings.EqualFold(s, source) {
+			return true
+		}
+	}
+	return false
+}
+
+// copyDirectives returns a shallow copy of a directive map.
+func copyDirectives(directives map[string][]string) map[string][]string {
+	out := make(map[string][]string, len(directives)+1)
+	for k, v := range directives {
+		out[k] = v
+	}
+	return out
+}
+
+// newNonce returns a random base64 nonce.
+func newNonce() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return base64.StdEncoding.EncodeToString(b[:])
+}
@@ -0,0 +1,163 @@
+package middleware
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/SailfinIO/sail/internal/securecookie"
+	"github.com/SailfinIO/sail/internal/server"
+	"github.com/SailfinIO/sail/internal/session"
+)
+
+// SessionOptions configures the Sessions middleware.
+type SessionOptions struct {
+	// Keyring signs, or with Encrypt also encrypts, the session cookie,
+	// e.g. sail.NewKeyringFromConfig. Either Keyring or Secrets is required.
+	Keyring *securecookie.Keyring
+	// Secrets are used when Keyring is nil. The first secret is used for
+	// new cookies and all are accepted, so secrets can be rotated by
+	// prepending a new one.
+	Secrets [][]byte
+	// Encrypt hides the session ID from the client with AES-GCM instead of
+	// only signing it.
+	Encrypt bool
+	// Store holds session data. Defaults to an in-memory store.
+	Store session.Store
+	// IdleTimeout expires sessions that are not used. Defaults to 30 minutes.
+	IdleTimeout time.Duration
+	// AbsoluteTimeout expires sessions this long after they were created or
+	// regenerated, however active. Defaults to 24 hours.
+	AbsoluteTimeout time.Duration
+	// CookieName defaults to "sail_session".
+	CookieName string
+	// CookiePath defaults to "/".
+	CookiePath   string
+	CookieDomain string
+	// CookieSecure marks the cookie Secure; enable it when serving over HTTPS.
+	CookieSecure bool
+	// CookieSameSite defaults to http.SameSiteLaxMode.
+	CookieSameSite http.SameSite
+}
+
+// Sessions returns a middleware that loads the client's server-side session
+// and saves it when the response is written. Handlers access it with
+// sail.Context.Session. The cookie carries only the signed or encrypted
+// session ID and is HttpOnly. New sessions are only stored and sent once
+// something is written to them. It panics if neither a keyring nor a
+// secret is given.
+func Sessions(opts SessionOptions) func(http.Handler) http.Handler {
+	if opts.Keyring == nil {
+		k, err := securecookie.NewKeyring(opts.Secrets...)
+		if err != nil {
+			panic("middleware: " + err.Error())
+		}
+		opts.Keyring = k
+	}
+	codec := opts.Keyring.Codec(opts.Encrypt)
+	if opts.Store == nil {
+		opts.Store = session.NewMemoryStore()
+	}
+	if opts.IdleTimeout <= 0 {
+		opts.IdleTimeout = 30 * time.Minute
+	}
+	if opts.AbsoluteTimeout <= 0 {
+		opts.AbsoluteTimeout = 24 * time.Hour
+	}
+	if opts.CookieName == "" {
+		opts.CookieName = "sail_session"
+	}
+	if opts.CookiePath == "" {
+		opts.CookiePath = "/"
+	}
+	if opts.CookieSameSite == 0 {
+		opts.CookieSameSite = http.SameSiteLaxMode
+	}
+	m := &session.Manager{
+		Store:           opts.Store,
+		IdleTimeout:     opts.IdleTimeout,
+		AbsoluteTimeout: opts.AbsoluteTimeout,
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := ""
+			if c, err := r.Cookie(opts.CookieName); err == nil {
+				id, _ = codec.Decode(opts.CookieName, c.Value, 0)
+			}
+			s, err := m.Load(r.Context(), id)
+			if err != nil {
+				server.LoggerFromContext(r.Context()).Error("session store: " + err.Error())
+				server.WriteError(w, err)
+				return
+			}
+			sw := &sessionWriter{
+				responseWriter: newResponseWriter(w),
+				commit: func(headerSent bool) {
+					id, write, err := m.Commit(r.Context(), s)
+					if err != nil {
+						server.LoggerFromContext(r.Context()).Error("session store: " + err.Error())
+						return
+					}
+					if !write {
+						return
+					}
+					if headerSent {
+						server.LoggerFromContext(r.Context()).Warn("session changed after the response was sent; cookie not updated")
+						return
+					}
+					cookie := &http.Cookie{
+						Name:     opts.CookieName,
+						Path:     opts.CookiePath,
+						Domain:   opts.CookieDomain,
+						Secure:   opts.CookieSecure,
+						HttpOnly: true,
+						SameSite: opts.CookieSameSite,
+					}
+					if id == "" {
+						cookie.MaxAge = -1
+					} else if cookie.Value, err = codec.Encode(opts.CookieName, id); err != nil {
+						server.LoggerFromContext(r.Context()).Error("session cookie: " + err.Error())
+						return
+					}
+					http.SetCookie(w, cookie)
+				},
+			}
+			next.ServeHTTP(sw, r.WithContext(session.WithSession(r.Context(), s)))
+			sw.commitOnce(sw.wroteHeader)
+			if sw.wroteHeader {
+				// Save changes made after the response started.
+				sw.commit(true)
+			}
+		})
+	}
+}
+
+// sessionWriter saves the session before the response header is sent, so
+// the session cookie can still be set.
+type sessionWriter struct {
+	*responseWriter
+	commit func(headerSent bool)
+	once   sync.Once
+}
+
+// commitOnce saves the session the first time it is called.
+func (w *sessionWriter) commitOnce(headerSent bool) {
+	w.once.Do(func() { w.commit(headerSent) })
+}
+
+func (w *sessionWriter) WriteHeader(code int) {
+	if code < 100 || code > 199 {
+		w.commitOnce(false)
+	}
+	w.responseWriter.WriteHeader(code)
+}
+
+func (w *sessionWriter) Write(b []byte) (int, error) {
+	w.commitOnce(false)
+	return w.responseWriter.Write(b)
+}
+
+func (w *sessionWriter) Flush() {
+	w.commitOnce(false)
+	w.responseWriter.Flush()
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/SailfinIO/sail/internal/session"
+)
+
+func TestSessions(t *testing.T) {
+	h := Sessions(SessionOptions{Secrets: [][]byte{[]byte("0123456789abcdef0123456789abcdef")}})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			s := session.FromContext(r.Context())
+			switch r.URL.Path {
+			case "/login":
+				s.Regenerate()
+				s.Set("user", "alice")
+			case "/logout":
+				s.Destroy()
+				s.Flash("info", "signed out")
+				return
+			case "/forget":
+				s.Destroy()
+			}
+			fmt.Fprintf(w, "%s|%v", s.GetString("user"), s.Flashes("info"))
+		}))
+	var cookie *http.Cookie
+	steps := []struct {
+		path   string
+		body   string
+		cookie string // "set", "clear" or "" when unchanged.
+	}{
+		{"/", "|[]", ""},
+		{"/login", "alice|[]", "set"},
+		{"/", "alice|[]", ""},
+		{"/logout", "", "set"},
+		{"/", "|[signed out]", ""},
+		{"/", "|[]", ""},
+		{"/forget", "|[]", "clear"},
+	}
+	for i, s := range steps {
+		r := httptest.NewRequest(http.MethodGet, s.path, nil)
+		if cookie != nil {
+			r.AddCookie(cookie)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Body.String() != s.body {
+			t.Fatalf("step %d: body = %q, want %q", i, w.Body.String(), s.body)
+		}
+		var set *http.Cookie
+		for _, c := range w.Result().Cookies() {
+			set = c
+		}
+		switch {
+		case s.cookie == "" && set != nil:
+			t.Fatalf("step %d: unexpected cookie %v", i, set)
+		case s.cookie == "set" && (set == nil || set.MaxAge < 0):
+			t.Fatalf("step %d: cookie = %v, want a session", i, set)
+		case s.cookie == "set" && cookie != nil && set.Value == cookie.Value:
+			t.Fatalf("step %d: session ID not rotated", i)
+		case s.cookie == "clear" && (set == nil || set.MaxAge >= 0):
+			t.Fatalf("step %d: cookie = %v, want it cleared", i, set)
+		}
+		if set != nil {
+			cookie = set
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/SailfinIO/sail/internal/server"
+)
+
+// TimeoutOptions configures the Timeout middleware.
+type TimeoutOptions struct {
+	// Timeout is the maximum time a handler may take.
+	Timeout time.Duration
+	// Status is sent when the handler times out: 503 (default) or 504.
+	Status int
+	// Message is the error message sent on timeout.
+	Message string
+}
+
+// Timeout returns a middleware that cancels the request context after the
+// configured duration and replies with an error if the handler has not
+// finished. The handler's output is buffered and only copied to the client
+// when it completes in time, so the handler and the timeout never write to
+// the ResponseWriter concurrently. Writes after the timeout fail with
+// http.ErrHandlerTimeout. Streaming responses are not supported.
+// It panics if Timeout is not positive.
+func Timeout(opts TimeoutOptions) func(http.Handler) http.Handler {
+	if opts.Timeout <= 0 {
+		panic("middleware: Timeout requires a positive Timeout")
+	}
+	if opts.Status == 0 {
+		opts.Status = http.StatusServiceUnavailable
+	}
+	if opts.Message == "" {
+		opts.Message = "Request timed out"
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), opts.Timeout)
+			defer cancel()
+			r = r.WithContext(ctx)
+			tw := &timeoutWriter{w: w, header: w.Header().Clone(), status: http.StatusOK}
+			done := make(chan struct{})
+			panicChan := make(chan handlerPanic, 1)
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						hp := handlerPanic{value: p, stack: debug.Stack()}
+						tw.mu.Lock()
+						defer tw.mu.Unlock()
+						if tw.timedOut {
+							// The timeout response has been sent and nobody
+							// waits for the handler any more.
+							logHandlerPanic(r, hp)
+							return
+						}
+						panicChan <- hp
+					}
+				}()
+				next.ServeHTTP(tw, r)
+				close(done)
+			}()
+			select {
+			case p := <-panicChan:
+				// Re-raise the original value so Recovery and the server see
+				// it unchanged; the handler's stack is only available here.
+				logHandlerPanic(r, p)
+				panic(p.value)
+			case <-done:
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				dst := w.Header()
+				for k := range dst {
+					delete(dst, k)
+				}
+				for k, v := range tw.header {
+					dst[k] = v
+				}
+				w.WriteHeader(tw.status)
+				w.Write(tw.buf.Bytes())
+			case <-ctx.Done():
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				select {
+				case p := <-panicChan:
+					// The handler panicked just as the timeout fired.
+					logHandlerPanic(r, p)
+					panic(p.value)
+				default:
+				}
+				tw.timedOut = true
+				server.LoggerFromContext(r.Context()).Warn(fmt.Sprintf("Request timed out after %s: %s %s", opts.Timeout, r.Method, r.URL.Path))
+				server.WriteError(w, &server.HTTPError{
+					Status:  opts.Status,
+					Code:    "timeout",
+					Message: opts.Message,
+				})
+			}
+		})
+	}
+}
+
+// handlerPanic carries a panic from the handler goroutine.
+type handlerPanic struct {
+	value interface{}
+	stack []byte
+}
+
+// logHandlerPanic logs a panic recovered from the handler goroutine, with
+// the handler's stack.
+func logHandlerPanic(r *http.Request, p handlerPanic) {
+	if p.value == http.ErrAbortHandler {
+		return
+	}
+	server.LoggerFromContext(r.Context()).Error(fmt.Sprintf("Panic in handler: %v [%s %s]\n%s", p.value, r.Method, r.URL.Path, p.stack))
+}
+
+// timeoutWriter buffers a handler's response until it completes.
+// Informational (1xx) responses are sent to w directly.
+type timeoutWriter struct {
+	w           http.ResponseWriter
+	mu          sync.Mutex
+	header      http.Header
+	buf         bytes.Buffer
+	status      int
+	wroteHeader bool
+	timedOut    bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+	if code >= 100 && code <= 199 {
+		dst := tw.w.Header()
+		for k, v := range tw.header {
+			dst[k] = v
+		}
+		tw.w.WriteHeader(code)
+		return
+	}
+	tw.wroteHeader = true
+	tw.status = code
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	tw.wroteHeader = true
+	return tw.buf.Write(b)
+}
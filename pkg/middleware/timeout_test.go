@@ -0,0 +1,195 @@
+package middleware
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SailfinIO/sail/internal/logger"
+	"github.com/SailfinIO/sail/internal/server"
+)
+
+type typedPanic struct{ code int }
+
+func TestTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler func(w http.ResponseWriter, r *http.Request)
+		status  int
+		body    string
+	}{
+		{"fast handler", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+			io.WriteString(w, "done")
+		}, http.StatusCreated, "done"},
+		{"slow handler", func(w http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+			io.WriteString(w, "late")
+		}, http.StatusServiceUnavailable, "timeout"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := Timeout(TimeoutOptions{Timeout: 50 * time.Millisecond})(http.HandlerFunc(tt.handler))
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			if w.Code != tt.status || !strings.Contains(w.Body.String(), tt.body) {
+				t.Fatalf("status = %d, body = %q", w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestTimeoutRejectsNonPositive(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Timeout accepted a zero duration")
+		}
+	}()
+	Timeout(TimeoutOptions{})
+}
+
+func TestTimeoutPreservesPanicValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+	}{
+		{"abort handler", http.ErrAbortHandler},
+		{"typed value", typedPanic{code: 7}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := Timeout(TimeoutOptions{Timeout: time.Second})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				panic(tt.value)
+			}))
+			defer func() {
+				got := recover()
+				if err, ok := tt.value.(error); ok {
+					if e, _ := got.(error); !errors.Is(e, err) {
+						t.Fatalf("recovered %v, want %v", got, tt.value)
+					}
+					return
+				}
+				if got != tt.value {
+					t.Fatalf("recovered %#v, want %#v", got, tt.value)
+				}
+			}()
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		})
+	}
+}
+
+// chanLogger sends every message to a channel, so that logs written from
+// the handler goroutine can be awaited.
+type chanLogger chan string
+
+func (l chanLogger) WithContext(string) logger.Logger { return l }
+func (l chanLogger) Info(msg string)                  { l <- msg }
+func (l chanLogger) Warn(msg string)                  { l <- msg }
+func (l chanLogger) Error(msg string)                 { l <- msg }
+func (l chanLogger) Debug(msg string)                 { l <- msg }
+
+func TestTimeoutLogsLatePanic(t *testing.T) {
+	logs := make(chanLogger, 4)
+	h := Timeout(TimeoutOptions{Timeout: 20 * time.Millisecond})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		time.Sleep(20 * time.Millisecond)
+		panic("late")
+	}))
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r = r.WithContext(server.WithLogger(r.Context(), logs))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d", w.Code)
+	}
+	for {
+		select {
+		case msg := <-logs:
+			if strings.HasPrefix(msg, "Panic in handler: late") {
+				return
+			}
+		case <-time.After(time.Second):
+			t.Fatal("panic after the timeout was not logged")
+		}
+	}
+}
+
+// informationalRecorder records the informational responses sent.
+type informationalRecorder struct {
+	*httptest.ResponseRecorder
+	informational []int
+	links         []string
+}
+
+func (w *informationalRecorder) WriteHeader(code int) {
+	if code >= 100 && code <= 199 {
+		w.informational = append(w.informational, code)
+		w.links = append(w.links, w.Header().Get("Link"))
+		return
+	}
+	w.ResponseRecorder.WriteHeader(code)
+}
+
+func TestTimeoutPassesInformational(t *testing.T) {
+	h := Timeout(TimeoutOptions{Timeout: time.Second})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Link", "</app.css>; rel=preload")
+		w.WriteHeader(http.StatusEarlyHints)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	w := &informationalRecorder{ResponseRecorder: httptest.NewRecorder()}
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if len(w.informational) != 1 || w.informational[0] != http.StatusEarlyHints || w.links[0] != "</app.css>; rel=preload" {
+		t.Fatalf("informational = %v, links = %q", w.informational, w.links)
+	}
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusAccepted)
+	}
+}
+
+func TestBodyLimitRejectsNonPositive(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("BodyLimit accepted a zero limit")
+		}
+	}()
+	BodyLimit(0)
+}
+
+func TestBodyLimit(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		chunked  bool
+		status   int
+		readFail bool
+	}{
+		{"within limit", "12345", false, http.StatusOK, false},
+		{"declared too large", "1234567890", false, http.StatusRequestEntityTooLarge, false},
+		{"streamed too large", "1234567890", true, http.StatusOK, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var readErr error
+			h := BodyLimit(5)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, readErr = io.ReadAll(r.Body)
+			}))
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			if tt.chunked {
+				r.ContentLength = -1
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			var maxErr *http.MaxBytesError
+			if tt.readFail != errors.As(readErr, &maxErr) {
+				t.Fatalf("read error = %v", readErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// hijackRecorder is a ResponseRecorder that supports Hijack and Push.
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+	hijacked bool
+	pushed   string
+}
+
+func (h *hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h.hijacked = true
+	c1, c2 := net.Pipe()
+	c2.Close()
+	return c1, bufio.NewReadWriter(bufio.NewReader(c1), bufio.NewWriter(c1)), nil
+}
+
+func (h *hijackRecorder) Push(target string, _ *http.PushOptions) error {
+	h.pushed = target
+	return nil
+}
+
+func TestResponseWriterOptionalInterfaces(t *testing.T) {
+	tests := []struct {
+		name       string
+		underlying http.ResponseWriter
+		supported  bool
+	}{
+		{"supported", &hijackRecorder{ResponseRecorder: httptest.NewRecorder()}, true},
+		{"unsupported", httptest.NewRecorder(), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hijackErr, pushErr error
+			h := Recovery(RecoveryOptions{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				hj, ok := w.(http.Hijacker)
+				if !ok {
+					t.Fatal("writer does not implement http.Hijacker")
+				}
+				p, ok := w.(http.Pusher)
+				if !ok {
+					t.Fatal("writer does not implement http.Pusher")
+				}
+				pushErr = p.Push("/app.js", nil)
+				var conn net.Conn
+				conn, _, hijackErr = hj.Hijack()
+				if conn != nil {
+					conn.Close()
+				}
+			}))
+			h.ServeHTTP(tt.underlying, httptest.NewRequest(http.MethodGet, "/", nil))
+			if tt.supported {
+				rec := tt.underlying.(*hijackRecorder)
+				if hijackErr != nil || !rec.hijacked || pushErr != nil || rec.pushed != "/app.js" {
+					t.Fatalf("hijack=%v (%v) push=%q (%v)", rec.hijacked, hijackErr, rec.pushed, pushErr)
+				}
+				return
+			}
+			if !errors.Is(hijackErr, http.ErrNotSupported) || !errors.Is(pushErr, http.ErrNotSupported) {
+				t.Fatalf("hijack err = %v, push err = %v, want ErrNotSupported", hijackErr, pushErr)
+			}
+		})
+	}
+}
+
+func TestRecoveryWritesError(t *testing.T) {
+	h := Recovery(RecoveryOptions{DisableStack: true})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", w.Code)
+	}
+}
@@ -2,9 +2,12 @@ package sail
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"github.com/SailfinIO/sail/internal/core"
 	"github.com/SailfinIO/sail/internal/logger"
 	"github.com/SailfinIO/sail/internal/server"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
@@ -19,22 +22,42 @@ type App struct {
 	httpServer     *server.HTTPServer
 	logger         logger.Logger
 	configService  *ConfigService
+	serverOptions  ServerOptions
 }
 
-// NewApp creates a new instance of App.
-func NewApp() *App {
+// NewApp creates a new instance of App. Options configure the HTTP server;
+// configuration keys read by ServerOptionsFromConfig take precedence, and
+// Run logs the resulting options when they do.
+func NewApp(opts ...AppOption) *App {
 	container := core.NewContainer()
 	moduleRegistry := core.NewModuleRegistry()
 	router := server.NewRouter()
 	logg := logger.New()
 	configService := NewConfigService()
-	return &App{
+	app := &App{
 		container:      container,
 		moduleRegistry: moduleRegistry,
 		router:         router,
 		logger:         logg,
 		configService:  configService,
+		serverOptions:  DefaultServerOptions(),
 	}
+	for _, opt := range opts {
+		opt(app)
+	}
+	router.UseGlobal(app.requestContext)
+	return app
+}
+
+// requestContext is the outermost global middleware. It attaches the
+// application logger and a fresh DI scope to the request context, so both
+// are available to global middleware as well as to routes.
+func (a *App) requestContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := server.WithLogger(r.Context(), a.logger)
+		ctx = server.WithScope(ctx, a.container.CreateScope())
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
 }
 
 // RegisterModule registers a module with the application.
@@ -51,16 +74,47 @@ func (a *App) Use(mw server.Middleware) {
 	a.router.Use(mw)
 }
 
+// UseGlobal adds a middleware that runs for every request before routing,
+// including requests that match no route.
+func (a *App) UseGlobal(mw server.Middleware) {
+	a.router.UseGlobal(mw)
+}
+
 // Router returns the application's router.
 func (a *App) Router() *Router {
 	return a.router
 }
 
+// Container returns the application's dependency injection container.
+func (a *App) Container() *Container {
+	return a.container
+}
+
+// Config returns the application's configuration service.
+func (a *App) Config() *ConfigService {
+	return a.configService
+}
+
 // Logger returns the application's logger.
 func (a *App) Logger() Logger {
 	return a.logger
 }
 
+// loadKeyring builds the cookie keyring from the app's configuration, so
+// values set with Config().Set are honored, and installs it as
+// DefaultKeyring. Without COOKIE_SECRETS the default keyring is left as is.
+func (a *App) loadKeyring() error {
+	k, err := NewKeyringFromConfig(a.configService, DefaultKeyringConfigKey)
+	if errors.Is(err, ErrNoKeyring) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	SetDefaultKeyring(k)
+	return nil
+}
+
 // Run initializes all modules and starts the HTTP server.
 // It also listens for interrupt signals to gracefully shut down.
 func (a *App) Run() {
@@ -70,10 +124,36 @@ func (a *App) Run() {
 		return
 	}
 
+	if err := a.loadKeyring(); err != nil {
+		a.logger.Error("Invalid cookie secrets: " + err.Error())
+		return
+	}
+
 	// Determine server port via ConfigService (defaulting to 8080).
 	addr := ":" + a.configService.Get("PORT", "8080")
 	a.httpServer = server.NewHTTPServer(addr, a.router)
-	a.logger.Info("Starting server on " + addr)
+	a.httpServer.SetLogger(a.logger)
+	serverOpts := ServerOptionsFromConfig(a.configService, a.serverOptions)
+	if serverOpts != a.serverOptions {
+		// Configuration wins over NewApp options so deployments can tune
+		// the server without a rebuild; say so rather than doing it silently.
+		a.logger.Info(fmt.Sprintf("SERVER_* configuration keys override the server options: %+v", serverOpts))
+	}
+	a.httpServer.Configure(serverOpts)
+	tlsCfg, err := TLSConfigFromConfig(a.configService)
+	if err != nil {
+		a.logger.Error("Invalid TLS configuration: " + err.Error())
+		return
+	}
+	if tlsCfg != nil {
+		if err := a.httpServer.EnableTLS(*tlsCfg); err != nil {
+			a.logger.Error("Failed to load TLS certificates: " + err.Error())
+			return
+		}
+		a.logger.Info("Starting HTTPS server on " + addr)
+	} else {
+		a.logger.Info("Starting server on " + addr)
+	}
 
 	// Start the HTTP server in a separate goroutine.
 	serverErrChan := make(chan error, 1)
@@ -0,0 +1,16 @@
+package sail
+
+import "github.com/SailfinIO/sail/internal/cache"
+
+// CacheStore is the public alias for cache.Store. Services invalidate cached
+// responses through it, e.g. store.InvalidateTags(ctx, "products").
+type CacheStore = cache.Store
+
+// CacheEntry is the public alias for cache.Entry.
+type CacheEntry = cache.Entry
+
+// MemoryCacheStore is the public alias for cache.MemoryStore.
+type MemoryCacheStore = cache.MemoryStore
+
+// NewMemoryCacheStore creates an in-memory LRU cache store.
+var NewMemoryCacheStore = cache.NewMemoryStore
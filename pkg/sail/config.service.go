@@ -5,6 +5,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // ConfigService provides configuration management similar in spirit to NestJS's ConfigService.
@@ -105,3 +106,22 @@ func (cs *ConfigService) GetBool(key string, defaultVal ...bool) bool {
 	}
 	return false
 }
+
+// GetDuration retrieves a configuration value as a duration, e.g. "30s" or
+// "1m30s". A plain integer is read as a number of seconds.
+func (cs *ConfigService) GetDuration(key string, defaultVal ...time.Duration) time.Duration {
+	str := cs.Get(key)
+	if str == "" && len(defaultVal) > 0 {
+		return defaultVal[0]
+	}
+	if d, err := time.ParseDuration(str); err == nil {
+		return d
+	}
+	if i, err := strconv.Atoi(str); err == nil {
+		return time.Duration(i) * time.Second
+	}
+	if len(defaultVal) > 0 {
+		return defaultVal[0]
+	}
+	return 0
+}
@@ -0,0 +1,33 @@
+package sail
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConfigServiceGetDuration(t *testing.T) {
+	const def = 7 * time.Second
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"30s", 30 * time.Second},
+		{"1m30s", 90 * time.Second},
+		{"90", 90 * time.Second},
+		{"0", 0},
+		{"soon", def},
+		{"1.5", def},
+		{"", def},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			cs := NewConfigService()
+			if tt.value != "" {
+				cs.Set("TEST_DURATION", tt.value)
+			}
+			if got := cs.GetDuration("TEST_DURATION", def); got != tt.want {
+				t.Fatalf("GetDuration(%q) = %s, want %s", tt.value, got, tt.want)
+			}
+		})
+	}
+}
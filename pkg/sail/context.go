@@ -0,0 +1,321 @@
+package sail
+
+import (
+	"context"
+	"errors"
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/SailfinIO/sail/internal/serializer"
+	"github.com/SailfinIO/sail/internal/server"
+)
+
+// Context carries the state of a single request and offers helpers for
+// writing the response. The underlying request and writer remain available.
+type Context struct {
+	Request *http.Request
+	Writer  http.ResponseWriter
+	status  int
+	written bool
+}
+
+// NewContext creates a Context for the given request.
+func NewContext(w http.ResponseWriter, r *http.Request) *Context {
+	c := &Context{Request: r, status: http.StatusOK}
+	c.Writer = &contextWriter{ResponseWriter: w, ctx: c}
+	return c
+}
+
+// HandlerFunc is a handler that receives a Context. A returned error is
+// written in the framework's error format if no response has been sent yet.
+type HandlerFunc func(c *Context) error
+
+// ServeHTTP makes HandlerFunc implement the http.Handler interface.
+func (f HandlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c := NewContext(w, r)
+	if err := f(c); err != nil {
+		if c.written {
+			c.Logger().Error("Handler error after response was written: " + err.Error())
+			return
+		}
+		var httpErr *HTTPError
+		if !errors.As(err, &httpErr) {
+			c.Logger().Error("Unhandled handler error: " + err.Error())
+		}
+		server.WriteError(c.Writer, err)
+	}
+}
+
+// contextWriter records whether the response has been started.
+type contextWriter struct {
+	http.ResponseWriter
+	ctx *Context
+}
+
+func (w *contextWriter) WriteHeader(code int) {
+	w.ctx.written = true
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *contextWriter) Write(b []byte) (int, error) {
+	w.ctx.written = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController.
+func (w *contextWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Context returns the request's context.Context.
+func (c *Context) Context() context.Context {
+	return c.Request.Context()
+}
+
+// Param returns the value of a path parameter.
+func (c *Context) Param(name string) string {
+	return c.Request.PathValue(name)
+}
+
+// Query returns the first value of a query parameter.
+func (c *Context) Query(name string) string {
+	return c.Request.URL.Query().Get(name)
+}
+
+// RequestID returns the ID assigned to this request, or an empty string.
+func (c *Context) RequestID() string {
+	return server.RequestIDFromContext(c.Context())
+}
+
+// Logger returns the logger bound to this request, including its request ID.
+func (c *Context) Logger() Logger {
+	return server.LoggerFromContext(c.Context())
+}
+
+// Scope returns the request-scoped DI container. Providers registered on it
+// live for the duration of the request; other names resolve from the application.
+// Requests served outside an App get a standalone container.
+func (c *Context) Scope() *Container {
+	if scope, ok := server.ScopeFromContext(c.Context()); ok {
+		return scope
+	}
+	scope := NewContainer()
+	c.Request = c.Request.WithContext(server.WithScope(c.Context(), scope))
+	return scope
+}
+
+// Route returns the matched route, or nil if the request was not dispatched by a Router.
+func (c *Context) Route() *Route {
+	route, _ := server.RouteFromContext(c.Context())
+	return route
+}
+
+// Metadata returns a metadata value attached to the matched route.
+func (c *Context) Metadata(key string) (interface{}, bool) {
+	route := c.Route()
+	if route == nil {
+		return nil, false
+	}
+	v, ok := route.Metadata[key]
+	return v, ok
+}
+
+// Principal returns the authenticated principal, or nil.
+func (c *Context) Principal() Principal {
+	p, _ := server.PrincipalFromContext(c.Context())
+	return p
+}
+
+// SetPrincipal attaches an authenticated principal to the request.
+func (c *Context) SetPrincipal(p Principal) {
+	c.Request = c.Request.WithContext(server.WithPrincipal(c.Context(), p))
+}
+
+// Status sets the status code used by the next response helper.
+func (c *Context) Status(code int) *Context {
+	c.status = code
+	return c
+}
+
+// Header returns the response headers.
+func (c *Context) Header() http.Header {
+	return c.Writer.Header()
+}
+
+// SetHeader sets a response header.
+func (c *Context) SetHeader(key, value string) *Context {
+	c.Writer.Header().Set(key, value)
+	return c
+}
+
+// SetETag sets the response's entity tag. The ETag middleware uses it instead
+// of hashing the body when answering conditional requests.
+func (c *Context) SetETag(tag string, weak bool) *Context {
+	c.Writer.Header().Set("ETag", server.FormatETag(tag, weak))
+	return c
+}
+
+// SetLastModified sets the Last-Modified response header.
+func (c *Context) SetLastModified(t time.Time) *Context {
+	c.Writer.Header().Set("Last-Modified", t.UTC().Format(http.TimeFormat))
+	return c
+}
+
+// CheckPreconditions evaluates If-Match, If-Unmodified-Since and If-None-Match
+// against the resource's current ETag and modification time, e.g. for
+// optimistic concurrency on PUT and PATCH. It returns a 412 HTTPError when a
+// precondition fails. Pass an empty etag if the resource does not exist.
+func (c *Context) CheckPreconditions(etag string, lastModified time.Time) error {
+	return server.CheckPreconditions(c.Request, server.FormatETag(etag, false), lastModified)
+}
+
+// Cookie returns the named request cookie.
+func (c *Context) Cookie(name string) (*http.Cookie, error) {
+	return c.Request.Cookie(name)
+}
+
+// SetCookie adds a Set-Cookie header to the response.
+func (c *Context) SetCookie(cookie *http.Cookie) *Context {
+	http.SetCookie(c.Writer, cookie)
+	return c
+}
+
+// Redirect replies with a redirect to url. The status defaults to 302 Found
+// unless a 3xx status was set with Status.
+func (c *Context) Redirect(url string) error {
+	code := c.status
+	if code < 300 || code > 399 {
+		code = http.StatusFound
+	}
+	http.Redirect(c.Writer, c.Request, url, code)
+	return nil
+}
+
+// JSON writes v as JSON with the current status. Like Render, it applies
+// Serialize with the request's serialization groups.
+func (c *Context) JSON(v interface{}) error {
+	c.Writer.Header().Set("Content-Type", "application/json")
+	c.Writer.WriteHeader(c.status)
+	return JSONCodec().Encode(c.Writer, Serialize(v, serializer.GroupsFromContext(c.Context())...))
+}
+
+// Render writes v in the format negotiated from the Accept header. See Render.
+func (c *Context) Render(v interface{}) error {
+	return Render(c.Writer, c.Request, c.status, v)
+}
+
+// CSPNonce returns the Content-Security-Policy nonce generated for this request
+// by the SecurityHeaders middleware, for <script nonce="..."> and
+// <style nonce="..."> elements. Template adds it to map data automatically.
+func (c *Context) CSPNonce() string {
+	return server.CSPNonceFromContext(c.Context())
+}
+
+// CSRFToken returns the CSRF token issued for this request by the CSRF
+// middleware, for embedding in forms or meta tags.
+func (c *Context) CSRFToken() string {
+	return server.CSRFTokenFromContext(c.Context())
+}
+
+// SetSignedCookie adds a cookie whose value is signed with DefaultKeyring.
+func (c *Context) SetSignedCookie(cookie *http.Cookie) error {
+	k, err := DefaultKeyring()
+	if err != nil {
+		return err
+	}
+	return SetSignedCookie(c.Writer, k, cookie)
+}
+
+// SignedCookie returns the verified value of a cookie set with SetSignedCookie.
+func (c *Context) SignedCookie(name string) (string, error) {
+	k, err := DefaultKeyring()
+	if err != nil {
+		return "", err
+	}
+	return SignedCookie(c.Request, k, name)
+}
+
+// SetEncryptedCookie adds a cookie whose value is encrypted with DefaultKeyring.
+func (c *Context) SetEncryptedCookie(cookie *http.Cookie) error {
+	k, err := DefaultKeyring()
+	if err != nil {
+		return err
+	}
+	return SetEncryptedCookie(c.Writer, k, cookie)
+}
+
+// EncryptedCookie returns the decrypted value of a cookie set with SetEncryptedCookie.
+func (c *Context) EncryptedCookie(name string) (string, error) {
+	k, err := DefaultKeyring()
+	if err != nil {
+		return "", err
+	}
+	return EncryptedCookie(c.Request, k, name)
+}
+
+// Session returns the request's session, or nil when the Sessions
+// middleware is not installed.
+func (c *Context) Session() *Session {
+	return SessionFromContext(c.Context())
+}
+
+// HTML writes a raw HTML string with the current status.
+func (c *Context) HTML(html string) error {
+	c.Writer.Header().Set("Content-Type", "text/html; charset=utf-8")
+	c.Writer.WriteHeader(c.status)
+	_, err := c.Writer.Write([]byte(html))
+	return err
+}
+
+// Template executes an HTML template with data and writes the result with the current status.
+// When data is nil or a map[string]interface{}, the request's CSP nonce and
+// CSRF token are added to it as "CSPNonce" and "CSRFToken" unless already
+// present, e.g. for <script nonce="{{.CSPNonce}}">.
+func (c *Context) Template(t *template.Template, data interface{}) error {
+	c.Writer.Header().Set("Content-Type", "text/html; charset=utf-8")
+	c.Writer.WriteHeader(c.status)
+	return t.Execute(c.Writer, c.templateData(data))
+}
+
+// templateData adds the request's CSP nonce and CSRF token to map data.
+func (c *Context) templateData(data interface{}) interface{} {
+	var m map[string]interface{}
+	switch d := data.(type) {
+	case nil:
+		m = make(map[string]interface{}, 2)
+	case map[string]interface{}:
+		m = make(map[string]interface{}, len(d)+2)
+		for k, v := range d {
+			m[k] = v
+		}
+	default:
+		return data
+	}
+	if _, ok := m["CSPNonce"]; !ok {
+		m["CSPNonce"] = c.CSPNonce()
+	}
+	if _, ok := m["CSRFToken"]; !ok {
+		m["CSRFToken"] = c.CSRFToken()
+	}
+	return m
+}
+
+// NoContent replies with 204 No Content.
+func (c *Context) NoContent() error {
+	c.Writer.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// Bind decodes the request body according to its Content-Type and validates it.
+// The returned error is an HTTPError suitable for returning from a HandlerFunc.
+func (c *Context) Bind(v interface{}) error {
+	if err := Decode(c.Request, v); err != nil {
+		return err
+	}
+	if err := Validate(v); err != nil {
+		logTagError(c.Request, err)
+		return ValidationError(err)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package sail
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SailfinIO/sail/internal/server"
+)
+
+func TestContextScopeResolvesAppProviders(t *testing.T) {
+	app := NewApp()
+	app.Container().Register("greeting", "hello")
+	var fromGlobal, fromRoute interface{}
+	app.UseGlobal(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fromGlobal, _ = NewContext(w, r).Scope().Resolve("greeting")
+			next.ServeHTTP(w, r)
+		})
+	})
+	app.Router().Handle("GET /", HandlerFunc(func(c *Context) error {
+		fromRoute, _ = c.Scope().Resolve("greeting")
+		return c.NoContent()
+	}))
+	app.Router().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if fromGlobal != "hello" || fromRoute != "hello" {
+		t.Fatalf("resolved %v from global middleware and %v from route, want hello", fromGlobal, fromRoute)
+	}
+}
+
+func TestContextJSONSerializes(t *testing.T) {
+	type user struct {
+		Name  string `json:"name"`
+		Email string `json:"email" sail:"groups=admin"`
+		Hash  string `json:"hash" sail:"exclude"`
+	}
+	tests := []struct {
+		name   string
+		groups []string
+		want   string
+	}{
+		{"public", nil, `{"name":"a"}`},
+		{"admin", []string{"admin"}, `{"name":"a","email":"a@example.com"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := WithSerializeGroups(httptest.NewRequest(http.MethodGet, "/", nil), tt.groups...)
+			w := httptest.NewRecorder()
+			if err := NewContext(w, r).JSON(user{"a", "a@example.com", "x"}); err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.TrimSpace(w.Body.String()); got != tt.want {
+				t.Fatalf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContextTemplateData(t *testing.T) {
+	tmpl := template.Must(template.New("page").Parse(`<script nonce="{{.CSPNonce}}">{{.Title}}</script>`))
+	tests := []struct {
+		name string
+		data interface{}
+		want string
+	}{
+		{"map", map[string]interface{}{"Title": "hi"}, `<script nonce="abc">"hi"</script>`},
+		{"explicit nonce wins", map[string]interface{}{"Title": "hi", "CSPNonce": "own"}, `<script nonce="own">"hi"</script>`},
+		{"struct", struct{ Title, CSPNonce string }{"hi", "field"}, `<script nonce="field">"hi"</script>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r = r.WithContext(server.WithCSPNonce(r.Context(), "abc"))
+			w := httptest.NewRecorder()
+			if err := NewContext(w, r).Template(tmpl, tt.data); err != nil {
+				t.Fatal(err)
+			}
+			if got := w.Body.String(); got != tt.want {
+				t.Fatalf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContextCheckPreconditions(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		etag   string
+		fail   bool
+	}{
+		{"if-match star on missing resource", "If-Match", "*", "", true},
+		{"if-none-match star on create", "If-None-Match", "*", "", false},
+		{"if-match current", "If-Match", `"v2"`, "v2", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPut, "/", nil)
+			r.Header.Set(tt.header, tt.value)
+			err := NewContext(httptest.NewRecorder(), r).CheckPreconditions(tt.etag, time.Time{})
+			if (err != nil) != tt.fail {
+				t.Fatalf("CheckPreconditions = %v, want failure %v", err, tt.fail)
+			}
+		})
+	}
+}
+
+func TestContextBindMalformedTag(t *testing.T) {
+	type dto struct {
+		Name string `json:"name" validate:"min=abc"`
+	}
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"a"}`))
+	r.Header.Set("Content-Type", "application/json")
+	err := NewContext(httptest.NewRecorder(), r).Bind(&dto{})
+	httpErr, ok := err.(*HTTPError)
+	if !ok || httpErr.Status != http.StatusInternalServerError || strings.Contains(httpErr.Message, "min") {
+		t.Fatalf("Bind = %v, want a 500 without tag details", err)
+	}
+}
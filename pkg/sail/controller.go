@@ -1,7 +1,6 @@
 package sail
 
 import (
-	"encoding/json"
 	"net/http"
 
 	"github.com/SailfinIO/sail/internal/server"
@@ -14,15 +13,67 @@ type Controller interface {
 }
 
 // BaseController can be embedded by controllers to reuse common functionality.
-type BaseController struct{}
+type BaseController struct {
+	// Keyring protects cookies written by the cookie helpers.
+	// Defaults to DefaultKeyring.
+	Keyring *Keyring
+}
 
-// WriteJSON is a helper to write data as JSON with appropriate headers.
-func (bc *BaseController) WriteJSON(w http.ResponseWriter, data interface{}) error {
-	w.Header().Set("Content-Type", "application/json")
-	return json.NewEncoder(w).Encode(data)
+// WriteJSON is a helper to write data with a 200 status. JSON is used unless
+// the request's Accept header selects another registered codec.
+// See Render for details.
+func (bc *BaseController) WriteJSON(w http.ResponseWriter, r *http.Request, data interface{}) error {
+	return Render(w, r, http.StatusOK, data)
 }
 
-// ReadJSON is a helper to decode JSON from the request body.
+// ReadJSON is a helper to decode the request body according to its
+// Content-Type, defaulting to JSON. See Decode for details.
 func (bc *BaseController) ReadJSON(r *http.Request, v interface{}) error {
-	return json.NewDecoder(r.Body).Decode(v)
+	return Decode(r, v)
+}
+
+// keyring returns the controller's keyring or the default one.
+func (bc *BaseController) keyring() (*Keyring, error) {
+	if bc.Keyring != nil {
+		return bc.Keyring, nil
+	}
+	return DefaultKeyring()
+}
+
+// SetSignedCookie is a helper to write a cookie whose value is signed.
+// See SetSignedCookie.
+func (bc *BaseController) SetSignedCookie(w http.ResponseWriter, cookie *http.Cookie) error {
+	k, err := bc.keyring()
+	if err != nil {
+		return err
+	}
+	return SetSignedCookie(w, k, cookie)
+}
+
+// SignedCookie is a helper to read a cookie written with SetSignedCookie.
+func (bc *BaseController) SignedCookie(r *http.Request, name string) (string, error) {
+	k, err := bc.keyring()
+	if err != nil {
+		return "", err
+	}
+	return SignedCookie(r, k, name)
+}
+
+// SetEncryptedCookie is a helper to write a cookie whose value is encrypted.
+// See SetEncryptedCookie.
+func (bc *BaseController) SetEncryptedCookie(w http.ResponseWriter, cookie *http.Cookie) error {
+	k, err := bc.keyring()
+	if err != nil {
+		return err
+	}
+	return SetEncryptedCookie(w, k, cookie)
+}
+
+// EncryptedCookie is a helper to read a cookie written with SetEncryptedCookie.
+func (bc *BaseController) EncryptedCookie(r *http.Request, name string) (string, error) {
+	k, err := bc.keyring()
+	if err != nil {
+		return "", err
+	}
+	return EncryptedCookie(r, k, name)
 }
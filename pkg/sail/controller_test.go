@@ -0,0 +1,71 @@
+package sail
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBaseControllerWriteJSON(t *testing.T) {
+	tests := []struct {
+		accept      string
+		status      int
+		contentType string
+	}{
+		{"", http.StatusOK, "application/json"},
+		{"application/xml", http.StatusOK, "application/xml"},
+		{"text/json", http.StatusOK, "application/json"},
+		{"text/html", http.StatusNotAcceptable, "application/json"},
+	}
+	var bc BaseController
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Accept", tt.accept)
+			w := httptest.NewRecorder()
+			_ = bc.WriteJSON(w, r, struct{ Name string }{"sail"})
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, tt.contentType) {
+				t.Fatalf("Content-Type = %q, want %q", ct, tt.contentType)
+			}
+		})
+	}
+}
+
+func TestBaseControllerReadJSON(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+	}{
+		{"json", "application/json", `{"Name":"a"}`, 0},
+		{"xml", "application/xml", `<x><Name>a</Name></x>`, 0},
+		{"malformed", "application/json", `{`, http.StatusBadRequest},
+		{"unsupported", "text/csv", `a`, http.StatusUnsupportedMediaType},
+		{"too large", "application/json", `{"Name":"` + strings.Repeat("a", 64) + `"}`, http.StatusRequestEntityTooLarge},
+	}
+	var bc BaseController
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", tt.contentType)
+			r.Body = http.MaxBytesReader(httptest.NewRecorder(), r.Body, 32)
+			var v struct{ Name string }
+			err := bc.ReadJSON(r, &v)
+			if tt.status == 0 {
+				if err != nil || v.Name != "a" {
+					t.Fatalf("ReadJSON = %v, %+v", err, v)
+				}
+				return
+			}
+			httpErr, ok := err.(*HTTPError)
+			if !ok || httpErr.Status != tt.status {
+				t.Fatalf("ReadJSON = %v, want status %d", err, tt.status)
+			}
+		})
+	}
+}
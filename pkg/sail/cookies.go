@@ -0,0 +1,123 @@
+package sail
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/SailfinIO/sail/internal/securecookie"
+)
+
+// DefaultKeyringConfigKey is the configuration key holding cookie secrets.
+const DefaultKeyringConfigKey = "COOKIE_SECRETS"
+
+// Keyring is the public alias for securecookie.Keyring.
+type Keyring = securecookie.Keyring
+
+// NewKeyring creates a keyring from secrets, newest first.
+var NewKeyring = securecookie.NewKeyring
+
+// Errors returned when reading protected cookies.
+var (
+	ErrInvalidCookie = securecookie.ErrInvalid
+	ErrNoKeyring     = errors.New("sail: no cookie secrets configured (" + DefaultKeyringConfigKey + ")")
+)
+
+// NewKeyringFromConfig creates a keyring from a comma-separated list of
+// secrets in the configuration key, newest first, e.g.
+// COOKIE_SECRETS=new-secret,old-secret. Secrets prefixed with "base64:"
+// are decoded. To rotate, prepend a new secret and drop the oldest once
+// cookies protected with it have expired.
+func NewKeyringFromConfig(cfg *ConfigService, key string) (*Keyring, error) {
+	value := cfg.Get(key)
+	if value == "" {
+		return nil, ErrNoKeyring
+	}
+	var secrets [][]byte
+	for _, s := range strings.Split(value, ",") {
+		s = strings.TrimSpace(s)
+		if encoded, ok := strings.CutPrefix(s, "base64:"); ok {
+			b, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				return nil, errors.New("sail: invalid base64 secret in " + key)
+			}
+			secrets = append(secrets, b)
+			continue
+		}
+		secrets = append(secrets, []byte(s))
+	}
+	return NewKeyring(secrets...)
+}
+
+// defaultKeyring is the keyring returned by DefaultKeyring once set.
+var defaultKeyring atomic.Pointer[Keyring]
+
+// DefaultKeyring returns the keyring used by the cookie helpers of
+// BaseController and Context. App.Run sets it from the app's COOKIE_SECRETS
+// when the app starts. Until then it is built from the environment on
+// first use; failures are not cached, so secrets configured later are
+// picked up.
+func DefaultKeyring() (*Keyring, error) {
+	if k := defaultKeyring.Load(); k != nil {
+		return k, nil
+	}
+	k, err := NewKeyringFromConfig(NewConfigService(), DefaultKeyringConfigKey)
+	if err != nil {
+		return nil, err
+	}
+	defaultKeyring.CompareAndSwap(nil, k)
+	return defaultKeyring.Load(), nil
+}
+
+// SetDefaultKeyring replaces the keyring returned by DefaultKeyring.
+func SetDefaultKeyring(k *Keyring) {
+	defaultKeyring.Store(k)
+}
+
+// SetSignedCookie signs the cookie's value with k and adds it to the
+// response. The client can read but not modify the value.
+func SetSignedCookie(w http.ResponseWriter, k *Keyring, cookie *http.Cookie) error {
+	value, err := k.Sign(cookie.Name, cookie.Value)
+	if err != nil {
+		return err
+	}
+	c := *cookie
+	c.Value = value
+	http.SetCookie(w, &c)
+	return nil
+}
+
+// SignedCookie returns the verified value of a cookie set with
+// SetSignedCookie, or ErrInvalidCookie if it was tampered with.
+func SignedCookie(r *http.Request, k *Keyring, name string) (string, error) {
+	c, err := r.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+	return k.Verify(name, c.Value, 0)
+}
+
+// SetEncryptedCookie encrypts the cookie's value with k and adds it to the
+// response. The client can neither read nor modify the value.
+func SetEncryptedCookie(w http.ResponseWriter, k *Keyring, cookie *http.Cookie) error {
+	value, err := k.Encrypt(cookie.Name, cookie.Value)
+	if err != nil {
+		return err
+	}
+	c := *cookie
+	c.Value = value
+	http.SetCookie(w, &c)
+	return nil
+}
+
+// EncryptedCookie returns the decrypted value of a cookie set with
+// SetEncryptedCookie, or ErrInvalidCookie if it was tampered with.
+func EncryptedCookie(r *http.Request, k *Keyring, name string) (string, error) {
+	c, err := r.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+	return k.Decrypt(name, c.Value, 0)
+}
@@ -0,0 +1,87 @@
+package sail
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDefaultKeyringDoesNotCacheFailures(t *testing.T) {
+	SetDefaultKeyring(nil)
+	t.Cleanup(func() { SetDefaultKeyring(nil) })
+	t.Setenv(DefaultKeyringConfigKey, "")
+	if _, err := DefaultKeyring(); !errors.Is(err, ErrNoKeyring) {
+		t.Fatalf("DefaultKeyring = %v, want ErrNoKeyring", err)
+	}
+	t.Setenv(DefaultKeyringConfigKey, "secret")
+	if k, err := DefaultKeyring(); err != nil || k == nil {
+		t.Fatalf("DefaultKeyring after configuring secrets = %v, %v", k, err)
+	}
+}
+
+func TestAppKeyringUsesAppConfig(t *testing.T) {
+	SetDefaultKeyring(nil)
+	t.Cleanup(func() { SetDefaultKeyring(nil) })
+	t.Setenv(DefaultKeyringConfigKey, "")
+	tests := []struct {
+		name    string
+		secrets string
+		fail    bool
+		set     bool
+	}{
+		{"unset", "", false, false},
+		{"set", "new,old", false, true},
+		{"invalid", "base64:!", true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetDefaultKeyring(nil)
+			app := NewApp()
+			if tt.secrets != "" {
+				app.Config().Set(DefaultKeyringConfigKey, tt.secrets)
+			}
+			if err := app.loadKeyring(); (err != nil) != tt.fail {
+				t.Fatalf("loadKeyring = %v", err)
+			}
+			if set := defaultKeyring.Load() != nil; set != tt.set {
+				t.Fatalf("default keyring set = %v, want %v", set, tt.set)
+			}
+		})
+	}
+}
+
+func TestSignedCookieRoundTrip(t *testing.T) {
+	current, _ := NewKeyring([]byte("new"), []byte("old"))
+	previous, _ := NewKeyring([]byte("old"))
+	other, _ := NewKeyring([]byte("other"))
+	tests := []struct {
+		name   string
+		writer *Keyring
+		want   error
+	}{
+		{"current secret", current, nil},
+		{"rotated secret", previous, nil},
+		{"unknown secret", other, ErrInvalidCookie},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, encrypt := range []bool{false, true} {
+				w := httptest.NewRecorder()
+				set, get := SetSignedCookie, SignedCookie
+				if encrypt {
+					set, get = SetEncryptedCookie, EncryptedCookie
+				}
+				if err := set(w, tt.writer, &http.Cookie{Name: "prefs", Value: "dark"}); err != nil {
+					t.Fatal(err)
+				}
+				r := httptest.NewRequest(http.MethodGet, "/", nil)
+				r.AddCookie(w.Result().Cookies()[0])
+				got, err := get(r, current, "prefs")
+				if !errors.Is(err, tt.want) || (err == nil && got != "dark") {
+					t.Fatalf("encrypt %v: got %q, %v, want %v", encrypt, got, err, tt.want)
+				}
+			}
+		})
+	}
+}
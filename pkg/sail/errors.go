@@ -0,0 +1,15 @@
+package sail
+
+import "github.com/SailfinIO/sail/internal/server"
+
+// HTTPError is the public alias for server.HTTPError.
+type HTTPError = server.HTTPError
+
+// NewHTTPError creates a new HTTPError with the given status and message.
+var NewHTTPError = server.NewHTTPError
+
+// ErrUnauthenticated returns the 401 error for requests without credentials.
+var ErrUnauthenticated = server.ErrUnauthenticated
+
+// WriteError writes an error using the framework's JSON error format.
+var WriteError = server.WriteError
@@ -0,0 +1,49 @@
+package sail
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/SailfinIO/sail/internal/server"
+)
+
+// RequestIDTransport is an http.RoundTripper that copies the request ID found
+// in an outgoing request's context into a header, so downstream services can
+// correlate their logs with the incoming request.
+type RequestIDTransport struct {
+	// Base performs the request. Defaults to http.DefaultTransport.
+	Base http.RoundTripper
+	// Header carries the ID. Defaults to X-Request-ID.
+	Header string
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *RequestIDTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	header := t.Header
+	if header == "" {
+		header = "X-Request-ID"
+	}
+	if id := server.RequestIDFromContext(req.Context()); id != "" && req.Header.Get(header) == "" {
+		// RoundTrippers must not modify the caller's request.
+		req = req.Clone(req.Context())
+		req.Header.Set(header, id)
+	}
+	return base.RoundTrip(req)
+}
+
+// NewHTTPClient returns an HTTP client that propagates request IDs.
+// Build outgoing requests with the incoming request's context, e.g.
+// http.NewRequestWithContext(c.Context(), ...), for the ID to be sent.
+func NewHTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: &RequestIDTransport{},
+	}
+}
+
+// RequestIDFromContext returns the ID of the request being served, or an empty string.
+var RequestIDFromContext = server.RequestIDFromContext
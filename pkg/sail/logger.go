@@ -7,3 +7,10 @@ type Logger = logger.Logger
 
 // NewLogger is a convenience function to create a new logger.
 var NewLogger = logger.New
+
+// FieldLogger is the public alias for logger.FieldLogger.
+type FieldLogger = logger.FieldLogger
+
+// LoggerWithField returns a logger that appends key=value to every message,
+// whether or not the logger implements FieldLogger.
+var LoggerWithField = logger.WithField
@@ -4,3 +4,9 @@ import "github.com/SailfinIO/sail/internal/core"
 
 // Module is an alias for core.Module to simplify usage.
 type Module = core.Module
+
+// Container is an alias for core.Container, the dependency injection container.
+type Container = core.Container
+
+// NewContainer creates a new dependency injection container.
+var NewContainer = core.NewContainer
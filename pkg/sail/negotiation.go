@@ -0,0 +1,91 @@
+package sail
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/SailfinIO/sail/internal/codec"
+	"github.com/SailfinIO/sail/internal/serializer"
+	"github.com/SailfinIO/sail/internal/server"
+)
+
+// Codec is the public alias for codec.Codec.
+type Codec = codec.Codec
+
+// JSONCodec and XMLCodec return the built-in codecs.
+var (
+	JSONCodec = codec.JSON
+	XMLCodec  = codec.XML
+)
+
+// RegisterCodec makes a codec available for content negotiation.
+// A codec registered for an existing media type replaces it.
+func RegisterCodec(c Codec) {
+	codec.Default().Register(c)
+}
+
+// Render encodes data with the codec that best matches the request's Accept
+// header and writes it with the given status. Data is first passed through
+// Serialize using the request's serialization groups. When no codec is
+// acceptable it writes a 406 response and returns the corresponding error.
+func Render(w http.ResponseWriter, r *http.Request, status int, data interface{}) error {
+	w.Header().Add("Vary", "Accept")
+	c, ok := codec.Default().Negotiate(r.Header.Get("Accept"))
+	if !ok {
+		err := &HTTPError{
+			Status:  http.StatusNotAcceptable,
+			Code:    "not_acceptable",
+			Message: "No acceptable representation for " + r.Header.Get("Accept"),
+		}
+		server.WriteError(w, err)
+		return err
+	}
+	w.Header().Set("Content-Type", c.ContentType())
+	w.WriteHeader(status)
+	return c.Encode(w, serializer.Serialize(data, serializer.GroupsFromContext(r.Context())...))
+}
+
+// Decode decodes the request body into v using the codec matching its
+// Content-Type header, defaulting to JSON when the header is absent.
+// It returns a 415 HTTPError for unsupported media types, a 413 HTTPError
+// for bodies over a limit set by http.MaxBytesReader and a 400 HTTPError
+// for malformed bodies.
+func Decode(r *http.Request, v interface{}) error {
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	c, ok := codec.Default().ForContentType(contentType)
+	if !ok {
+		return &HTTPError{
+			Status:  http.StatusUnsupportedMediaType,
+			Code:    "unsupported_media_type",
+			Message: "Unsupported media type " + contentType,
+		}
+	}
+	if err := c.Decode(r.Body, v); err != nil {
+		var httpErr *HTTPError
+		if errors.As(err, &httpErr) {
+			return err
+		}
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return &HTTPError{
+				Status:  http.StatusRequestEntityTooLarge,
+				Code:    "payload_too_large",
+				Message: "Request body exceeds " + strconv.FormatInt(maxErr.Limit, 10) + " bytes",
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			err = errors.New("empty body")
+		}
+		return &HTTPError{
+			Status:  http.StatusBadRequest,
+			Code:    "invalid_body",
+			Message: "Invalid request body: " + err.Error(),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package sail
+
+import (
+	"time"
+
+	"github.com/SailfinIO/sail/internal/server"
+)
+
+// ServerOptions is the public alias for server.ServerOptions.
+type ServerOptions = server.ServerOptions
+
+// DefaultServerOptions returns the server settings used by NewApp.
+var DefaultServerOptions = server.DefaultServerOptions
+
+// AppOption configures an App created by NewApp.
+type AppOption func(*App)
+
+// WithServerOptions replaces all HTTP server settings.
+func WithServerOptions(opts ServerOptions) AppOption {
+	return func(a *App) {
+		a.serverOptions = opts
+	}
+}
+
+// WithReadTimeout sets the maximum time to read a request, including the body.
+func WithReadTimeout(d time.Duration) AppOption {
+	return func(a *App) {
+		a.serverOptions.ReadTimeout = d
+	}
+}
+
+// WithReadHeaderTimeout sets the maximum time to read request headers.
+func WithReadHeaderTimeout(d time.Duration) AppOption {
+	return func(a *App) {
+		a.serverOptions.ReadHeaderTimeout = d
+	}
+}
+
+// WithWriteTimeout sets the maximum time to write a response.
+func WithWriteTimeout(d time.Duration) AppOption {
+	return func(a *App) {
+		a.serverOptions.WriteTimeout = d
+	}
+}
+
+// WithIdleTimeout sets how long keep-alive connections wait for the next request.
+func WithIdleTimeout(d time.Duration) AppOption {
+	return func(a *App) {
+		a.serverOptions.IdleTimeout = d
+	}
+}
+
+// WithMaxHeaderBytes limits the size of request headers.
+func WithMaxHeaderBytes(n int) AppOption {
+	return func(a *App) {
+		a.serverOptions.MaxHeaderBytes = n
+	}
+}
+
+// WithKeepAlives enables or disables HTTP keep-alive connections.
+func WithKeepAlives(enabled bool) AppOption {
+	return func(a *App) {
+		a.serverOptions.DisableKeepAlives = !enabled
+	}
+}
+
+// WithH2C serves HTTP/2 over cleartext connections, e.g. behind a proxy
+// or service mesh that terminates TLS.
+func WithH2C() AppOption {
+	return func(a *App) {
+		a.serverOptions.H2C = true
+	}
+}
+
+// ServerOptionsFromConfig overrides base with configuration keys that are set:
+//
+//	SERVER_READ_TIMEOUT         e.g. "30s"
+//	SERVER_READ_HEADER_TIMEOUT  e.g. "5s"
+//	SERVER_WRITE_TIMEOUT        e.g. "1m"
+//	SERVER_IDLE_TIMEOUT         e.g. "2m"
+//	SERVER_MAX_HEADER_BYTES     bytes
+//	SERVER_KEEP_ALIVES          true or false
+//	SERVER_H2C                  true or false
+//
+// Durations may also be given in seconds; "0" disables a timeout.
+func ServerOptionsFromConfig(cfg *ConfigService, base ServerOptions) ServerOptions {
+	opts := base
+	opts.ReadTimeout = cfg.GetDuration("SERVER_READ_TIMEOUT", base.ReadTimeout)
+	opts.ReadHeaderTimeout = cfg.GetDuration("SERVER_READ_HEADER_TIMEOUT", base.ReadHeaderTimeout)
+	opts.WriteTimeout = cfg.GetDuration("SERVER_WRITE_TIMEOUT", base.WriteTimeout)
+	opts.IdleTimeout = cfg.GetDuration("SERVER_IDLE_TIMEOUT", base.IdleTimeout)
+	opts.MaxHeaderBytes = cfg.GetInt("SERVER_MAX_HEADER_BYTES", base.MaxHeaderBytes)
+	opts.DisableKeepAlives = !cfg.GetBool("SERVER_KEEP_ALIVES", !base.DisableKeepAlives)
+	opts.H2C = cfg.GetBool("SERVER_H2C", base.H2C)
+	return opts
+}
@@ -0,0 +1,33 @@
+package sail
+
+import (
+	"testing"
+	"time"
+)
+
+func TestServerOptionsFromConfig(t *testing.T) {
+	app := NewApp(WithReadTimeout(5*time.Second), WithWriteTimeout(20*time.Second), WithH2C())
+	cfg := NewConfigService()
+	cfg.Set("SERVER_READ_TIMEOUT", "30s")
+	cfg.Set("SERVER_IDLE_TIMEOUT", "0")
+	cfg.Set("SERVER_MAX_HEADER_BYTES", "4096")
+	cfg.Set("SERVER_KEEP_ALIVES", "false")
+	cfg.Set("SERVER_WRITE_TIMEOUT", "invalid")
+
+	got := ServerOptionsFromConfig(cfg, app.serverOptions)
+	want := ServerOptions{
+		ReadTimeout:       30 * time.Second, // Configuration wins over WithReadTimeout.
+		ReadHeaderTimeout: DefaultServerOptions().ReadHeaderTimeout,
+		WriteTimeout:      20 * time.Second, // Invalid values keep the NewApp option.
+		IdleTimeout:       0,
+		MaxHeaderBytes:    4096,
+		DisableKeepAlives: true,
+		H2C:               true,
+	}
+	if got != want {
+		t.Fatalf("ServerOptionsFromConfig = %+v, want %+v", got, want)
+	}
+	if unchanged := ServerOptionsFromConfig(NewConfigService(), app.serverOptions); unchanged != app.serverOptions {
+		t.Fatalf("without configuration = %+v, want %+v", unchanged, app.serverOptions)
+	}
+}
@@ -0,0 +1,139 @@
+package sail
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/SailfinIO/sail/internal/pipes"
+	"github.com/SailfinIO/sail/internal/server"
+)
+
+// Pipe is the public alias for pipes.Pipe.
+type Pipe = pipes.Pipe
+
+// PipeFunc is the public alias for pipes.PipeFunc.
+type PipeFunc = pipes.PipeFunc
+
+// PipeMetadata is the public alias for pipes.Metadata.
+type PipeMetadata = pipes.Metadata
+
+// Built-in pipes.
+var (
+	ParseIntPipe     = pipes.ParseInt
+	ParseFloatPipe   = pipes.ParseFloat
+	ParseBoolPipe    = pipes.ParseBool
+	ParseUUIDPipe    = pipes.ParseUUID
+	ParseEnumPipe    = pipes.ParseEnum
+	DefaultValuePipe = pipes.DefaultValue
+	TrimPipe         = pipes.Trim
+)
+
+// NewPipeError builds the 400 error a custom pipe should return when it rejects a value.
+var NewPipeError = pipes.NewError
+
+// PathParam returns the path parameter name, transformed by any route pipes
+// and then by the given pipes.
+func PathParam(r *http.Request, name string, p ...Pipe) (interface{}, error) {
+	return param(r, pipes.Metadata{Source: pipes.SourcePath, Name: name}, p)
+}
+
+// QueryParam returns the first value of the query parameter name, transformed
+// by any route pipes and then by the given pipes.
+func QueryParam(r *http.Request, name string, p ...Pipe) (interface{}, error) {
+	return param(r, pipes.Metadata{Source: pipes.SourceQuery, Name: name}, p)
+}
+
+// HeaderParam returns the first value of the header name, transformed by any
+// route pipes and then by the given pipes.
+func HeaderParam(r *http.Request, name string, p ...Pipe) (interface{}, error) {
+	return param(r, pipes.Metadata{Source: pipes.SourceHeader, Name: http.CanonicalHeaderKey(name)}, p)
+}
+
+// param reads a parameter, preferring a value already transformed by route pipes.
+func param(r *http.Request, meta pipes.Metadata, p []Pipe) (interface{}, error) {
+	value, ok := pipes.Value(r.Context(), meta)
+	if !ok {
+		value = pipes.Raw(r, meta)
+	}
+	return pipes.Apply(value, meta, p...)
+}
+
+// UsePipes returns a middleware that runs every path parameter of the
+// matched route through the given pipes before the handler is called.
+// It needs the matched route, so install it with Use or on a group, not
+// with UseGlobal. Query parameters and headers have no fixed set of names;
+// transform them with QueryPipes and HeaderPipes.
+func UsePipes(p ...Pipe) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, name := range patternParams(r.Pattern) {
+				var ok bool
+				if r, ok = applyPipes(w, r, pipes.Metadata{Source: pipes.SourcePath, Name: name}, p); !ok {
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ParamPipes returns a middleware that transforms the path parameter name
+// before the handler is called. Handlers read the result with PathParam.
+func ParamPipes(name string, p ...Pipe) Middleware {
+	return sourcePipes(pipes.Metadata{Source: pipes.SourcePath, Name: name}, p)
+}
+
+// QueryPipes returns a middleware that transforms the query parameter name
+// before the handler is called. Handlers read the result with QueryParam.
+func QueryPipes(name string, p ...Pipe) Middleware {
+	return sourcePipes(pipes.Metadata{Source: pipes.SourceQuery, Name: name}, p)
+}
+
+// HeaderPipes returns a middleware that transforms the header name before
+// the handler is called. Handlers read the result with HeaderParam.
+func HeaderPipes(name string, p ...Pipe) Middleware {
+	return sourcePipes(pipes.Metadata{Source: pipes.SourceHeader, Name: http.CanonicalHeaderKey(name)}, p)
+}
+
+// sourcePipes builds a middleware applying pipes to a single parameter.
+func sourcePipes(meta pipes.Metadata, p []Pipe) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r, ok := applyPipes(w, r, meta, p); ok {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// applyPipes transforms a parameter and records the result on the request.
+// On failure it writes the error and returns false.
+func applyPipes(w http.ResponseWriter, r *http.Request, meta pipes.Metadata, p []Pipe) (*http.Request, bool) {
+	value, err := param(r, meta, p)
+	if err != nil {
+		server.WriteError(w, err)
+		return r, false
+	}
+	return r.WithContext(pipes.WithValue(r.Context(), meta, value)), true
+}
+
+// patternParams extracts the wildcard names from a ServeMux pattern
+// such as "GET /items/{id}/{rest...}".
+func patternParams(pattern string) []string {
+	var names []string
+	for {
+		start := strings.IndexByte(pattern, '{')
+		if start < 0 {
+			return names
+		}
+		end := strings.IndexByte(pattern[start:], '}')
+		if end < 0 {
+			return names
+		}
+		name := strings.TrimSuffix(pattern[start+1:start+end], "...")
+		if name != "$" && name != "" {
+			names = append(names, name)
+		}
+		pattern = pattern[start+end+1:]
+	}
+}
@@ -0,0 +1,46 @@
+package sail
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestUsePipes(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		header string
+		status int
+		body   string
+	}{
+		{"converted", "/items/7?sort=desc", "", http.StatusOK, "int 7 desc tenant=default"},
+		{"defaults", "/items/7", "acme", http.StatusOK, "int 7 asc tenant=acme"},
+		{"bad path param", "/items/abc", "", http.StatusBadRequest, ""},
+		{"bad query param", "/items/7?sort=sideways", "", http.StatusBadRequest, ""},
+	}
+	router := NewRouter()
+	router.Use(UsePipes(ParseIntPipe()))
+	router.Use(QueryPipes("sort", DefaultValuePipe("asc"), ParseEnumPipe("asc", "desc")))
+	router.Use(HeaderPipes("x-tenant", DefaultValuePipe("default")))
+	router.Handle("GET /items/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, _ := PathParam(r, "id")
+		sort, _ := QueryParam(r, "sort")
+		tenant, _ := HeaderParam(r, "X-Tenant")
+		fmt.Fprintf(w, "%T %v %v tenant=%v", id, id, sort, tenant)
+	}))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.header != "" {
+				r.Header.Set("X-Tenant", tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+			if w.Code != tt.status || (tt.body != "" && w.Body.String() != tt.body) {
+				t.Fatalf("got %d %q, want %d %q", w.Code, w.Body.String(), tt.status, tt.body)
+			}
+		})
+	}
+}
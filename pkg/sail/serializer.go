@@ -0,0 +1,27 @@
+package sail
+
+import (
+	"net/http"
+
+	"github.com/SailfinIO/sail/internal/serializer"
+)
+
+// Serialize returns v with fields tagged `sail:"exclude"` removed and fields
+// tagged `sail:"groups=..."` kept only when one of groups matches.
+var Serialize = serializer.Serialize
+
+// SerializeGroups returns a middleware that selects the serialization groups
+// used by Render and Context.JSON for every response of a route.
+func SerializeGroups(groups ...string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, WithSerializeGroups(r, groups...))
+		})
+	}
+}
+
+// WithSerializeGroups returns a shallow copy of r whose responses are
+// serialized with the given groups, e.g. after inspecting the caller's role.
+func WithSerializeGroups(r *http.Request, groups ...string) *http.Request {
+	return r.WithContext(serializer.WithGroups(r.Context(), groups...))
+}
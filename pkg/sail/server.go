@@ -8,6 +8,33 @@ type Router = server.Router
 // Middleware is the public alias for server.Middleware.
 type Middleware = server.Middleware
 
+// RouteGroup is the public alias for server.Group.
+type RouteGroup = server.Group
+
+// Route is the public alias for server.Route.
+type Route = server.Route
+
+// RouteOption is the public alias for server.RouteOption.
+type RouteOption = server.RouteOption
+
+// Principal is the public alias for server.Principal.
+type Principal = server.Principal
+
+// Guard is the public alias for server.Guard.
+type Guard = server.Guard
+
+// GuardFunc is the public alias for server.GuardFunc.
+type GuardFunc = server.GuardFunc
+
+// UseGuards returns a middleware that runs guards before the handler.
+var UseGuards = server.UseGuards
+
+// Authenticated is a guard that requires a principal on the request.
+var Authenticated = server.Authenticated
+
+// SetMetadata attaches a metadata value to a route at registration time.
+var SetMetadata = server.SetMetadata
+
 // NewHTTPServer creates a new HTTPServer instance.
 var NewHTTPServer = server.NewHTTPServer
 
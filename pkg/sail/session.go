@@ -0,0 +1,31 @@
+package sail
+
+import (
+	"context"
+
+	"github.com/SailfinIO/sail/internal/session"
+)
+
+// Session is the public alias for session.Session.
+type Session = session.Session
+
+// SessionStore is the public alias for session.Store.
+type SessionStore = session.Store
+
+// MemorySessionStore is the public alias for session.MemoryStore.
+type MemorySessionStore = session.MemoryStore
+
+// FileSessionStore is the public alias for session.FileStore.
+type FileSessionStore = session.FileStore
+
+// NewMemorySessionStore creates an in-memory session store.
+var NewMemorySessionStore = session.NewMemoryStore
+
+// NewFileSessionStore creates a session store that keeps one file per session in a directory.
+var NewFileSessionStore = session.NewFileStore
+
+// SessionFromContext returns the request's session, or nil when the
+// session middleware is not installed.
+func SessionFromContext(ctx context.Context) *Session {
+	return session.FromContext(ctx)
+}
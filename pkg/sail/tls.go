@@ -0,0 +1,59 @@
+package sail
+
+import (
+	"errors"
+	"time"
+
+	"github.com/SailfinIO/sail/internal/server"
+)
+
+// TLSConfig is the public alias for server.TLSConfig.
+type TLSConfig = server.TLSConfig
+
+// TLSConfigFromConfig reads the HTTPS settings from configuration keys:
+//
+//	TLS_CERT_FILE        PEM certificate chain (enables HTTPS)
+//	TLS_KEY_FILE         PEM private key
+//	TLS_MIN_VERSION      minimum version, e.g. "1.2" (default) or "1.3"
+//	TLS_CIPHER_SUITES    comma-separated TLS 1.2 cipher suite names
+//	TLS_CLIENT_CA_FILE   PEM client CAs; enables mutual TLS
+//	TLS_CLIENT_AUTH      none, request, require, verify_if_given or
+//	                     require_and_verify (default with a client CA)
+//	TLS_RELOAD_INTERVAL  seconds between checks for changed files
+//	                     (default 60, 0 disables; SIGHUP always reloads)
+//
+// It returns nil when TLS_CERT_FILE is not set.
+func TLSConfigFromConfig(cfg *ConfigService) (*TLSConfig, error) {
+	certFile := cfg.Get("TLS_CERT_FILE")
+	if certFile == "" {
+		return nil, nil
+	}
+	tlsCfg := &TLSConfig{
+		CertFile:       certFile,
+		KeyFile:        cfg.Get("TLS_KEY_FILE"),
+		ClientCAFile:   cfg.Get("TLS_CLIENT_CA_FILE"),
+		ReloadInterval: time.Duration(cfg.GetInt("TLS_RELOAD_INTERVAL", 60)) * time.Second,
+	}
+	if tlsCfg.KeyFile == "" {
+		return nil, errors.New("TLS_KEY_FILE is required when TLS_CERT_FILE is set")
+	}
+	var err error
+	if v := cfg.Get("TLS_MIN_VERSION"); v != "" {
+		if tlsCfg.MinVersion, err = server.ParseTLSVersion(v); err != nil {
+			return nil, err
+		}
+	}
+	if v := cfg.Get("TLS_CIPHER_SUITES"); v != "" {
+		if tlsCfg.CipherSuites, err = server.ParseCipherSuites(v); err != nil {
+			return nil, err
+		}
+	}
+	if v := cfg.Get("TLS_CLIENT_AUTH"); v != "" {
+		auth, err := server.ParseClientAuth(v)
+		if err != nil {
+			return nil, err
+		}
+		tlsCfg.ClientAuth = &auth
+	}
+	return tlsCfg, nil
+}
@@ -0,0 +1,35 @@
+package sail
+
+import (
+	"crypto/tls"
+	"testing"
+)
+
+func TestTLSConfigFromConfigClientAuth(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  *tls.ClientAuthType
+	}{
+		{"unset", "", nil},
+		{"none", "none", new(tls.ClientAuthType)},
+		{"verify if given", "verify_if_given", func() *tls.ClientAuthType { a := tls.VerifyClientCertIfGiven; return &a }()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := NewConfigService()
+			cfg.Set("TLS_CERT_FILE", "cert.pem")
+			cfg.Set("TLS_KEY_FILE", "key.pem")
+			cfg.Set("TLS_CLIENT_CA_FILE", "ca.pem")
+			cfg.Set("TLS_CLIENT_AUTH", tt.value)
+			tlsCfg, err := TLSConfigFromConfig(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := tlsCfg.ClientAuth
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Fatalf("ClientAuth = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package sail
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/SailfinIO/sail/internal/server"
+	"github.com/SailfinIO/sail/internal/validation"
+)
+
+// Validator is the public alias for validation.Validator.
+type Validator = validation.Validator
+
+// ValidationField is the public alias for validation.Field.
+type ValidationField = validation.Field
+
+// FieldError is the public alias for validation.FieldError.
+type FieldError = validation.FieldError
+
+// ValidationErrors is the public alias for validation.Errors.
+type ValidationErrors = validation.Errors
+
+// ValidationTagError is the public alias for validation.TagError.
+type ValidationTagError = validation.TagError
+
+// NewValidator creates a Validator with the built-in rules registered.
+var NewValidator = validation.New
+
+// Validate checks v against its `validate` struct tags using the default validator.
+func Validate(v interface{}) error {
+	return validation.Validate(v)
+}
+
+// RegisterValidator adds a custom rule to the default validator.
+// The message may contain %s, which is replaced with the rule argument.
+func RegisterValidator(name string, fn func(f ValidationField) bool, message string) {
+	validation.Default().RegisterRule(name, fn, message)
+}
+
+// RegisterStructValidator adds a cross-field rule for the type of sample to the default validator.
+func RegisterStructValidator(sample interface{}, fn func(v interface{}) ValidationErrors) {
+	validation.Default().RegisterStructRule(sample, fn)
+}
+
+// ValidationError converts an error returned by Validate into a 400 HTTPError
+// listing every failing field. Malformed validate tags are a server bug and
+// become a 500.
+func ValidationError(err error) *HTTPError {
+	var tagErr *validation.TagError
+	if errors.As(err, &tagErr) {
+		return NewHTTPError(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+	}
+	var verrs validation.Errors
+	if errors.As(err, &verrs) {
+		return &HTTPError{
+			Status:  http.StatusBadRequest,
+			Code:    "validation_failed",
+			Message: "Validation failed",
+			Details: verrs,
+		}
+	}
+	return &HTTPError{
+		Status:  http.StatusBadRequest,
+		Code:    "invalid_request",
+		Message: err.Error(),
+	}
+}
+
+// Bind returns a handler that decodes the request body into a new T,
+// validates it and only then calls handler. The body is decoded according
+// to its Content-Type. Invalid bodies are rejected with a 400 before the
+// handler runs. Bind panics if T's validate tags name an unknown rule.
+func Bind[T any](handler func(w http.ResponseWriter, r *http.Request, body *T)) http.Handler {
+	if err := validation.Default().Check(new(T)); err != nil {
+		panic(err)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := new(T)
+		if err := Decode(r, body); err != nil {
+			server.WriteError(w, err)
+			return
+		}
+		if err := Validate(body); err != nil {
+			logTagError(r, err)
+			server.WriteError(w, ValidationError(err))
+			return
+		}
+		handler(w, r, body)
+	})
+}
+
+// logTagError logs malformed validate tags, which ValidationError hides
+// from the client.
+func logTagError(r *http.Request, err error) {
+	var tagErr *validation.TagError
+	if errors.As(err, &tagErr) {
+		server.LoggerFromContext(r.Context()).Error(err.Error())
+	}
+}